@@ -5,30 +5,64 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"sort"
 
 	"github.com/korjavin/fastfooddb/internal/importer"
 )
 
 func main() {
-	dump := flag.String("dump", "", "path to gzip-compressed JSONL dump (required)")
+	dump := flag.String("dump", "", "path to JSONL dump, plain or gzip/zstd/bzip2 compressed; - for stdin (required)")
 	out := flag.String("out", "", "output data directory (required)")
-	verbose := flag.Bool("v", false, "print progress every 100k products")
+	verbose := flag.Bool("v", false, "print progress (with ETA for files) every 100k products")
+	duplicates := flag.String("duplicates", string(importer.DuplicateLatest),
+		"which record wins for a repeated barcode: latest (last_modified_t) or complete (most nutriments)")
+	samples := flag.Int("report-samples", importer.DefaultReportSamples,
+		"offending lines sampled per skip reason into "+importer.ReportFile)
+	dryRun := flag.Bool("dry-run", false, "only validate the dump and write "+importer.ReportFile+"; build no stores")
+	filterExpr := flag.String("filter", "",
+		`keep only matching records, e.g. 'countries_tags contains "en:germany" && has(protein) && has(kcal)'`)
+	shards := flag.Int("shards", 1, "split the search index into this many shards, queried in parallel")
+	ingest := flag.Bool("ingest", false, "sort records on disk and ingest them as SSTables instead of batch commits (faster for full dumps)")
+	barcodeIndex := flag.Bool("barcode-index", false, "also write the memory-mapped perfect-hash barcode index used for lookups")
 	flag.Parse()
 
 	if *dump == "" || *out == "" {
-		fmt.Fprintln(os.Stderr, "usage: fastfooddb-importer -dump <path> -out <dir> [-v]")
+		fmt.Fprintln(os.Stderr, "usage: fastfooddb-importer -dump <path> -out <dir> [-duplicates latest|complete] [-filter expr] [-shards n] [-ingest] [-barcode-index] [-report-samples n] [-dry-run] [-v]")
 		os.Exit(1)
 	}
 
+	policy, err := importer.ParseDuplicatePolicy(*duplicates)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var filter *importer.Filter
+	if *filterExpr != "" {
+		if filter, err = importer.ParseFilter(*filterExpr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
 	slog.SetDefault(logger)
 
-	slog.Info("starting import", "dump", *dump, "out", *out)
+	slog.Info("starting import", "dump", *dump, "out", *out, "dry_run", *dryRun)
 
-	m, err := importer.Import(*dump, *out, *verbose)
+	m, err := importer.Import(*dump, *out, importer.Options{
+		Verbose:       *verbose,
+		Duplicates:    policy,
+		ReportSamples: *samples,
+		DryRun:        *dryRun,
+		Filter:        filter,
+		Shards:        *shards,
+		Ingest:        *ingest,
+		BarcodeIndex:  *barcodeIndex,
+	})
 	if err != nil {
 		slog.Error("import failed", "error", err)
 		os.Exit(1)
@@ -38,20 +72,30 @@ func main() {
 		"products", m.ProductCount,
 		"indexed", m.IndexedCount,
 		"skipped", m.SkippedCount,
+		"index_bytes", m.IndexBytes,
+		"index_segments", m.IndexSegments,
 		"build_time", m.BuildTime,
 	)
 	fmt.Printf("Output: %s\n  Products stored : %d\n  Names indexed   : %d\n  Skipped         : %d\n",
 		*out, m.ProductCount, m.IndexedCount, m.SkippedCount)
 
-	if len(m.SkipReasons) > 0 {
-		fmt.Println("  Skip reasons:")
-		keys := make([]string, 0, len(m.SkipReasons))
-		for k := range m.SkipReasons {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			fmt.Printf("    %-20s: %d\n", k, m.SkipReasons[k])
-		}
+	printCounts("Skip reasons", m.SkipReasons)
+	printCounts("Rejected nutriments", m.FieldRejections)
+	fmt.Printf("  Report          : %s\n", filepath.Join(*out, importer.ReportFile))
+}
+
+// printCounts prints a sorted counter map under title, if it is non-empty.
+func printCounts(title string, counts map[string]int64) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Printf("  %s:\n", title)
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("    %-20s: %d\n", k, counts[k])
 	}
 }
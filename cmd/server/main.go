@@ -3,14 +3,20 @@ package main
 import (
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"syscall"
 	"time"
 
+	"google.golang.org/grpc"
+
 	"github.com/korjavin/fastfooddb/internal/api"
 	"github.com/korjavin/fastfooddb/internal/auth"
+	"github.com/korjavin/fastfooddb/internal/grpcapi"
 	"github.com/korjavin/fastfooddb/internal/metrics"
 	"github.com/korjavin/fastfooddb/internal/middleware"
 	"github.com/korjavin/fastfooddb/internal/store"
@@ -25,6 +31,11 @@ func main() {
 		port = "8080"
 	}
 
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9090"
+	}
+
 	dataDir := os.Getenv("DATA_DIR")
 	if dataDir == "" {
 		slog.Error("DATA_DIR environment variable is required")
@@ -41,14 +52,57 @@ func main() {
 		corsOrigins = "*"
 	}
 
-	slog.Info("opening store", "data_dir", dataDir)
-	s, err := store.OpenReadOnly(dataDir)
+	pebbleOpts := store.PebbleOptions{
+		BlockCacheSize: int64(envInt("PEBBLE_CACHE_MB", store.DefaultBlockCacheSize>>20)) << 20,
+		MaxOpenFiles:   envInt("PEBBLE_MAX_OPEN_FILES", store.DefaultMaxOpenFiles),
+	}
+
+	slog.Info("opening store", "data_dir", dataDir,
+		"pebble_cache_bytes", pebbleOpts.BlockCacheSize, "pebble_max_open_files", pebbleOpts.MaxOpenFiles)
+	s, err := store.OpenReadOnlyWith(dataDir, pebbleOpts)
 	if err != nil {
 		slog.Error("failed to open store", "error", err)
 		os.Exit(1)
 	}
 	defer s.Close()
 
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+
+	if cacheMB := envInt("CACHE_SIZE_MB", 64); cacheMB > 0 {
+		s.EnableCache(int64(cacheMB) << 20)
+		go s.WatchCacheGeneration(watchCtx, 30*time.Second)
+		slog.Info("result cache enabled", "size_mb", cacheMB)
+	}
+
+	synonymsPath := os.Getenv("SYNONYMS_FILE")
+	if synonymsPath == "" {
+		if p := filepath.Join(dataDir, store.SynonymsFile); fileExists(p) {
+			synonymsPath = p
+		}
+	}
+	if synonymsPath != "" {
+		sy, err := store.LoadSynonyms(synonymsPath)
+		if err != nil {
+			slog.Error("failed to load synonyms", "path", synonymsPath, "error", err)
+			os.Exit(1)
+		}
+		s.SetSynonyms(sy)
+		go sy.Watch(watchCtx, 30*time.Second)
+		slog.Info("synonyms loaded", "path", synonymsPath)
+	}
+
+	backend := os.Getenv("SEARCH_BACKEND")
+	t0 := time.Now()
+	searcher, err := store.NewSearcher(backend, s)
+	if err != nil {
+		slog.Error("failed to set up search backend", "backend", backend, "error", err)
+		os.Exit(1)
+	}
+	if ts, ok := searcher.(*store.TrigramSearcher); ok {
+		slog.Info("trigram index built", "names", ts.Len(), "elapsed", time.Since(t0).Round(time.Millisecond))
+	}
+
 	manifest, err := store.ReadManifest(dataDir)
 	if err != nil {
 		slog.Warn("manifest not found or unreadable", "error", err)
@@ -63,14 +117,17 @@ func main() {
 
 	reg := metrics.NewRegistry()
 	mux := http.NewServeMux()
-	api.RegisterRoutes(mux, apiKeys, s, manifest, reg)
+	api.RegisterRoutes(mux, apiKeys, s, searcher, manifest, reg)
+
+	// HTTP and gRPC clients share one per-IP rate limit.
+	limiter := middleware.NewLimiter(100, 20)
 
 	// Middleware chain (outer to inner): Logging → CORS → RateLimit → mux
 	handler := middleware.Chain(
 		mux,
 		middleware.Logging(logger),
 		middleware.CORS(corsOrigins),
-		middleware.RateLimit(100, 20),
+		middleware.RateLimitWith(limiter),
 	)
 
 	srv := &http.Server{
@@ -89,6 +146,27 @@ func main() {
 		}
 	}()
 
+	// Interceptor chain (outer to inner): Logging → RateLimit → APIKey
+	gs := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		grpcapi.Logging(logger),
+		grpcapi.RateLimit(limiter),
+		grpcapi.APIKey(apiKeys),
+	))
+	grpcapi.Register(gs, s, searcher, backend, manifest, reg)
+
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		slog.Error("grpc listen failed", "port", grpcPort, "error", err)
+		os.Exit(1)
+	}
+	go func() {
+		slog.Info("grpc server starting", "port", grpcPort)
+		if err := gs.Serve(lis); err != nil {
+			slog.Error("grpc server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -100,6 +178,36 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		slog.Error("server forced to shutdown", "error", err)
 	}
+	stopped := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		gs.Stop()
+	}
 
 	slog.Info("server exited")
 }
+
+// envInt reads a non-negative integer from the environment, returning def
+// when the variable is unset. An invalid value is fatal.
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		slog.Error("invalid integer environment variable", "name", name, "value", v)
+		os.Exit(1)
+	}
+	return n
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+)
+
+const (
+	maxCalculateItems = 100
+	maxCalculateBody  = 64 << 10
+	maxItemGrams      = 100_000
+)
+
+// calculateRequest is the body of POST /api/v1/food/calculate.
+type calculateRequest struct {
+	Items []calculateItem `json:"items"`
+}
+
+type calculateItem struct {
+	Barcode string  `json:"barcode"`
+	Grams   float64 `json:"grams"`
+}
+
+// calculateItemResponse holds the nutrients of one portion. Missing lists
+// the nutrients the product has no value for; those are null here and left
+// out of the total.
+type calculateItemResponse struct {
+	Barcode string   `json:"barcode"`
+	Name    string   `json:"name,omitempty"`
+	Grams   float64  `json:"grams"`
+	Found   bool     `json:"found"`
+	Kcal    *float32 `json:"kcal"`
+	Protein *float32 `json:"protein"`
+	Fat     *float32 `json:"fat"`
+	Carbs   *float32 `json:"carbs"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+type calculateTotal struct {
+	Grams   float64 `json:"grams"`
+	Kcal    float32 `json:"kcal"`
+	Protein float32 `json:"protein"`
+	Fat     float32 `json:"fat"`
+	Carbs   float32 `json:"carbs"`
+}
+
+// calculateResponse carries per-item values and their sum. Partial is true
+// when any item was not found or lacks a nutrient, so Total understates the
+// meal.
+type calculateResponse struct {
+	Items   []calculateItemResponse `json:"items"`
+	Total   calculateTotal          `json:"total"`
+	Partial bool                    `json:"partial"`
+}
+
+// FoodCalculate sums kcal and macros for a meal given as barcode + grams
+// portions, scaling the stored per-100g values.
+func (h *Handler) FoodCalculate(w http.ResponseWriter, r *http.Request) {
+	var req calculateRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCalculateBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validateCalculateItems(req.Items); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	t0 := time.Now()
+	resp := calculateResponse{Items: make([]calculateItemResponse, len(req.Items))}
+	for i, it := range req.Items {
+		p, found, err := h.Products.Get(it.Barcode)
+		if err != nil {
+			slog.Error("calculate lookup failed", "barcode", it.Barcode, "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		item := calculateItemResponse{Barcode: it.Barcode, Grams: it.Grams, Found: found}
+		resp.Total.Grams += it.Grams
+		if !found {
+			item.Missing = []string{"kcal", "protein", "fat", "carbs"}
+			resp.Items[i] = item
+			resp.Partial = true
+			continue
+		}
+		item.Name = p.Name
+
+		scale := float32(it.Grams / 100)
+		portion := func(name string, per100g float32, total *float32) *float32 {
+			if math.IsNaN(float64(per100g)) {
+				item.Missing = append(item.Missing, name)
+				resp.Partial = true
+				return nil
+			}
+			v := per100g * scale
+			*total += v
+			return &v
+		}
+		item.Kcal = portion("kcal", p.Kcal100g, &resp.Total.Kcal)
+		item.Protein = portion("protein", p.Protein, &resp.Total.Protein)
+		item.Fat = portion("fat", p.Fat, &resp.Total.Fat)
+		item.Carbs = portion("carbs", p.Carbs, &resp.Total.Carbs)
+		resp.Items[i] = item
+	}
+	if h.CalculateHist != nil {
+		h.CalculateHist.Observe(time.Since(t0))
+	}
+
+	writeResponse(w, r, http.StatusOK, resp)
+}
+
+func validateCalculateItems(items []calculateItem) error {
+	if len(items) == 0 {
+		return fmt.Errorf("items must not be empty")
+	}
+	if len(items) > maxCalculateItems {
+		return fmt.Errorf("at most %d items allowed", maxCalculateItems)
+	}
+	for i, it := range items {
+		if it.Barcode == "" {
+			return fmt.Errorf("items[%d]: missing barcode", i)
+		}
+		if !(it.Grams > 0 && it.Grams <= maxItemGrams) {
+			return fmt.Errorf("items[%d]: grams must be in (0, %d]", i, maxItemGrams)
+		}
+	}
+	return nil
+}
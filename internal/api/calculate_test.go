@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/korjavin/fastfooddb/internal/store"
+)
+
+// fakeProducts serves a fixed product set.
+type fakeProducts map[string]store.Product
+
+func (f fakeProducts) Get(bc string) (store.Product, bool, error) {
+	p, ok := f[bc]
+	return p, ok, nil
+}
+
+func (f fakeProducts) ScanPrefix(prefix, cursor string, limit int) ([]store.Product, string, error) {
+	return nil, "", nil
+}
+
+func (f fakeProducts) SearchWith(q string, opts store.SearchOptions) ([]store.Product, error) {
+	var out []store.Product
+	for _, bc := range slices.Sorted(maps.Keys(f)) {
+		if strings.Contains(strings.ToLower(f[bc].Name), strings.ToLower(q)) {
+			out = append(out, f[bc])
+		}
+	}
+	return out, nil
+}
+
+func (f fakeProducts) Suggest(q string, k int) ([]store.Suggestion, error) {
+	return []store.Suggestion{{Text: "nutella", Weight: 7}}, nil
+}
+
+func (f fakeProducts) DidYouMean(q string) (string, error) { return "nutella", nil }
+
+var testProducts = fakeProducts{
+	"3017620422003": {Barcode: "3017620422003", Name: "Nutella", Kcal100g: 539, Protein: 6.3, Fat: 30.9, Carbs: 57.5},
+	"5000112637922": {Barcode: "5000112637922", Name: "Coca-Cola", Kcal100g: 42, Protein: float32(math.NaN()), Fat: 0, Carbs: 10.6},
+}
+
+func postCalculate(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	h := &Handler{Products: testProducts}
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/food/calculate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.FoodCalculate(w, r)
+	return w
+}
+
+func TestFoodCalculate(t *testing.T) {
+	w := postCalculate(t, `{"items": [
+		{"barcode": "3017620422003", "grams": 20},
+		{"barcode": "5000112637922", "grams": 330},
+		{"barcode": "0000000000000", "grams": 50}
+	]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", w.Code, w.Body)
+	}
+	var resp struct {
+		Items []struct {
+			Barcode string   `json:"barcode"`
+			Name    string   `json:"name"`
+			Found   bool     `json:"found"`
+			Kcal    *float32 `json:"kcal"`
+			Protein *float32 `json:"protein"`
+			Missing []string `json:"missing"`
+		} `json:"items"`
+		Total   calculateTotal `json:"total"`
+		Partial bool           `json:"partial"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Items) != 3 {
+		t.Fatalf("got %d items, want 3", len(resp.Items))
+	}
+
+	nutella, cola, unknown := resp.Items[0], resp.Items[1], resp.Items[2]
+	if nutella.Name != "Nutella" || nutella.Kcal == nil || *nutella.Kcal != 107.8 || len(nutella.Missing) != 0 {
+		t.Errorf("nutella = %+v", nutella)
+	}
+	// A NaN macro is null on the item and left out of the total.
+	if cola.Protein != nil || !slices.Equal(cola.Missing, []string{"protein"}) {
+		t.Errorf("cola protein = %v, missing %v; want null, [protein]", cola.Protein, cola.Missing)
+	}
+	if unknown.Found || unknown.Kcal != nil || !slices.Equal(unknown.Missing, []string{"kcal", "protein", "fat", "carbs"}) {
+		t.Errorf("unknown barcode = %+v", unknown)
+	}
+
+	want := calculateTotal{Grams: 400, Kcal: 107.8 + 138.6, Protein: 6.3 * 0.2, Fat: 30.9 * 0.2, Carbs: 57.5*0.2 + 10.6*3.3}
+	if math.Abs(resp.Total.Grams-want.Grams) > 1e-9 ||
+		math.Abs(float64(resp.Total.Kcal-want.Kcal)) > 0.01 ||
+		math.Abs(float64(resp.Total.Protein-want.Protein)) > 0.01 ||
+		math.Abs(float64(resp.Total.Fat-want.Fat)) > 0.01 ||
+		math.Abs(float64(resp.Total.Carbs-want.Carbs)) > 0.01 {
+		t.Errorf("total = %+v, want %+v", resp.Total, want)
+	}
+	if !resp.Partial {
+		t.Errorf("partial = false, want true")
+	}
+}
+
+func TestFoodCalculate_Complete(t *testing.T) {
+	w := postCalculate(t, `{"items": [{"barcode": "3017620422003", "grams": 100}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", w.Code, w.Body)
+	}
+	var resp calculateResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Partial || resp.Total.Kcal != 539 {
+		t.Errorf("partial = %v, total kcal = %v; want false, 539", resp.Partial, resp.Total.Kcal)
+	}
+}
+
+func TestFoodCalculate_CSV(t *testing.T) {
+	h := &Handler{Products: testProducts}
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/food/calculate?format=csv", strings.NewReader(`{"items": [
+		{"barcode": "3017620422003", "grams": 100},
+		{"barcode": "0000000000000", "grams": 50}
+	]}`))
+	w := httptest.NewRecorder()
+	h.FoodCalculate(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", w.Code, w.Body)
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if got, want := lines[len(lines)-1], "total,,150,,539,6.3,30.9,57.5,,true"; got != want {
+		t.Errorf("last row = %q, want %q", got, want)
+	}
+}
+
+func TestFoodCalculate_Invalid(t *testing.T) {
+	tooMany := make([]string, maxCalculateItems+1)
+	for i := range tooMany {
+		tooMany[i] = `{"barcode": "3017620422003", "grams": 1}`
+	}
+
+	tests := []struct {
+		name, body string
+	}{
+		{"not json", `items`},
+		{"unknown field", `{"items": [{"barcode": "3017620422003", "grams": 10, "unit": "oz"}]}`},
+		{"unknown top-level field", `{"items": [{"barcode": "3017620422003", "grams": 10}], "meal": "lunch"}`},
+		{"no items", `{"items": []}`},
+		{"missing barcode", `{"items": [{"grams": 10}]}`},
+		{"zero grams", `{"items": [{"barcode": "3017620422003", "grams": 0}]}`},
+		{"negative grams", `{"items": [{"barcode": "3017620422003", "grams": -5}]}`},
+		{"too many grams", fmt.Sprintf(`{"items": [{"barcode": "3017620422003", "grams": %d}]}`, maxItemGrams+1)},
+		{"too many items", `{"items": [` + strings.Join(tooMany, ",") + `]}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := postCalculate(t, tc.body); w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", w.Code)
+			}
+		})
+	}
+}
+
+func TestValidateCalculateItems_NaNGrams(t *testing.T) {
+	// JSON cannot carry NaN, but the range check must still reject it.
+	err := validateCalculateItems([]calculateItem{{Barcode: "3017620422003", Grams: math.NaN()}})
+	if err == nil {
+		t.Errorf("NaN grams accepted")
+	}
+}
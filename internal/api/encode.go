@@ -0,0 +1,308 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/korjavin/fastfooddb/internal/foodpb"
+)
+
+// Response formats selectable with format= or the Accept header.
+const (
+	formatJSON     = "json"
+	formatMsgpack  = "msgpack"
+	formatProtobuf = "protobuf"
+	formatCSV      = "csv"
+)
+
+// formatMediaTypes maps the media types understood in Accept to formats.
+var formatMediaTypes = map[string]string{
+	"application/json":        formatJSON,
+	"application/msgpack":     formatMsgpack,
+	"application/x-msgpack":   formatMsgpack,
+	"application/vnd.msgpack": formatMsgpack,
+	"application/x-protobuf":  formatProtobuf,
+	"application/protobuf":    formatProtobuf,
+	"text/csv":                formatCSV,
+}
+
+// formatContentTypes is the Content-Type each format is served with.
+// Protobuf responses add the messageType parameter.
+var formatContentTypes = map[string]string{
+	formatJSON:     "application/json",
+	formatMsgpack:  "application/msgpack",
+	formatProtobuf: "application/x-protobuf",
+	formatCSV:      "text/csv; charset=utf-8",
+}
+
+// protoResponse is a response with a foodpb message counterpart.
+type protoResponse interface {
+	protoMessage() proto.Message
+}
+
+// csvResponse is a response that lays out as a table.
+type csvResponse interface {
+	csvTable() (header []string, rows [][]string)
+}
+
+// negotiateFormat picks the response format: format= wins, then the
+// supported media type with the highest q in Accept. Anything else,
+// including no preference, gets JSON.
+func negotiateFormat(r *http.Request) (string, error) {
+	if f := r.URL.Query().Get("format"); f != "" {
+		if _, ok := formatContentTypes[f]; !ok {
+			return "", fmt.Errorf("format must be json, msgpack, protobuf or csv")
+		}
+		return f, nil
+	}
+	best, bestQ := formatJSON, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		f, ok := formatMediaTypes[mt]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = f, q
+		}
+	}
+	return best, nil
+}
+
+// writeResponse encodes v in the format the request negotiated. MessagePack
+// encodes v itself under its JSON field names. Protobuf and CSV need v to
+// implement protoResponse or csvResponse; other responses get 406.
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, v any) {
+	format, err := negotiateFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Add("Vary", "Accept")
+	if format == formatJSON {
+		writeJSON(w, status, v)
+		return
+	}
+
+	contentType := formatContentTypes[format]
+	var body []byte
+	switch format {
+	case formatMsgpack:
+		body, err = encodeMsgpack(v)
+	case formatProtobuf:
+		pr, ok := v.(protoResponse)
+		if !ok {
+			http.Error(w, "protobuf is not available for this endpoint", http.StatusNotAcceptable)
+			return
+		}
+		m := pr.protoMessage()
+		contentType += fmt.Sprintf("; messageType=%q", m.ProtoReflect().Descriptor().FullName())
+		body, err = proto.Marshal(m)
+	case formatCSV:
+		cr, ok := v.(csvResponse)
+		if !ok {
+			http.Error(w, "csv is not available for this endpoint", http.StatusNotAcceptable)
+			return
+		}
+		body, err = encodeCSV(cr.csvTable())
+	}
+	if err != nil {
+		slog.Error("failed to encode response", "format", format, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		slog.Error("failed to write response", "error", err)
+	}
+}
+
+// encodeMsgpack encodes v with its JSON field names and omitempty options,
+// so float32 nutrients stay float32 and nil nutrients are nil.
+func encodeMsgpack(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeCSV(header []string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Product CSV columns. Nested fields are dotted and lists joined with ";".
+var (
+	productColumns = []string{
+		"barcode", "name", "brand", "kcal100g", "protein", "fat", "carbs",
+		"alternate_barcodes", "gs1_country.code", "gs1_country.name",
+		"gs1_country.prefix_range", "quality_flags",
+	}
+	portionColumns = []string{
+		"portion.amount", "portion.unit", "portion.grams", "portion.kcal",
+		"portion.kj", "portion.protein", "portion.fat", "portion.carbs",
+	}
+)
+
+// productTable lays out one product per row. The portion columns are
+// present when the products carry a portion.
+func productTable(ps []productResponse) ([]string, [][]string) {
+	withPortion := slices.ContainsFunc(ps, func(p productResponse) bool { return p.Portion != nil })
+	header := productColumns
+	if withPortion {
+		header = slices.Concat(productColumns, portionColumns)
+	}
+
+	rows := make([][]string, len(ps))
+	for i, p := range ps {
+		var gs1 gs1CountryResponse
+		if p.GS1Country != nil {
+			gs1 = *p.GS1Country
+		}
+		row := make([]string, 0, len(header))
+		row = append(row,
+			p.Barcode, p.Name, p.Brand,
+			csvFloat32(p.Kcal100g), csvFloat32(p.Protein), csvFloat32(p.Fat), csvFloat32(p.Carbs),
+			strings.Join(p.Alternates, ";"), gs1.Code, gs1.Name, gs1.PrefixRange,
+			strings.Join(p.Quality, ";"),
+		)
+		if pr := p.Portion; pr != nil {
+			row = append(row,
+				csvFloat64(pr.Amount), pr.Unit, csvFloat64(pr.Grams),
+				csvFloat32(pr.Kcal.ptr()), csvFloat32(pr.KJ.ptr()),
+				csvFloat32(pr.Protein.ptr()), csvFloat32(pr.Fat.ptr()), csvFloat32(pr.Carbs.ptr()),
+			)
+		} else if withPortion {
+			row = append(row, make([]string, len(portionColumns))...)
+		}
+		rows[i] = row
+	}
+	return header, rows
+}
+
+// csvFloat32 formats a nutrient; missing ones are empty cells.
+func csvFloat32(f *float32) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(float64(*f), 'f', -1, 32)
+}
+
+func csvFloat64(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func (p productResponse) csvTable() ([]string, [][]string) {
+	return productTable([]productResponse{p})
+}
+
+func (p productResponse) protoMessage() proto.Message { return p.proto() }
+
+// proto converts p to its foodpb counterpart; nutrients that are null in
+// JSON are left unset.
+func (p productResponse) proto() *foodpb.Product {
+	out := &foodpb.Product{
+		Barcode:           p.Barcode,
+		Name:              p.Name,
+		Brand:             p.Brand,
+		Kcal100G:          p.Kcal100g,
+		Protein:           p.Protein,
+		Fat:               p.Fat,
+		Carbs:             p.Carbs,
+		AlternateBarcodes: p.Alternates,
+		QualityFlags:      p.Quality,
+	}
+	if c := p.GS1Country; c != nil {
+		out.Gs1Country = &foodpb.GS1Country{Code: c.Code, Name: c.Name, PrefixRange: c.PrefixRange}
+	}
+	if pr := p.Portion; pr != nil {
+		out.Portion = &foodpb.Portion{
+			Amount:  pr.Amount,
+			Unit:    pr.Unit,
+			Grams:   pr.Grams,
+			Kcal:    pr.Kcal.ptr(),
+			Kj:      pr.KJ.ptr(),
+			Protein: pr.Protein.ptr(),
+			Fat:     pr.Fat.ptr(),
+			Carbs:   pr.Carbs.ptr(),
+		}
+	}
+	return out
+}
+
+func (s searchResponse) csvTable() ([]string, [][]string) {
+	return productTable(s.Results)
+}
+
+func (s searchResponse) protoMessage() proto.Message {
+	out := &foodpb.SearchProductsResponse{
+		Results:    make([]*foodpb.Product, len(s.Results)),
+		Suggestion: s.Suggestion,
+	}
+	for i, p := range s.Results {
+		out.Results[i] = p.proto()
+	}
+	return out
+}
+
+func (p prefixResponse) csvTable() ([]string, [][]string) {
+	return productTable(p.Results)
+}
+
+func (s suggestResponse) csvTable() ([]string, [][]string) {
+	rows := make([][]string, len(s.Suggestions))
+	for i, sg := range s.Suggestions {
+		rows[i] = []string{sg.Text, strconv.FormatUint(sg.Weight, 10)}
+	}
+	return []string{"text", "weight"}, rows
+}
+
+// csvTable lays out one row per item and a final "total" row carrying the
+// sums and, in the partial column, whether they understate the meal.
+func (c calculateResponse) csvTable() ([]string, [][]string) {
+	rows := make([][]string, 0, len(c.Items)+1)
+	for _, it := range c.Items {
+		rows = append(rows, []string{
+			it.Barcode, it.Name, csvFloat64(it.Grams), strconv.FormatBool(it.Found),
+			csvFloat32(it.Kcal), csvFloat32(it.Protein), csvFloat32(it.Fat), csvFloat32(it.Carbs),
+			strings.Join(it.Missing, ";"), "",
+		})
+	}
+	t := c.Total
+	rows = append(rows, []string{
+		"total", "", csvFloat64(t.Grams), "",
+		csvFloat32(&t.Kcal), csvFloat32(&t.Protein), csvFloat32(&t.Fat), csvFloat32(&t.Carbs),
+		"", strconv.FormatBool(c.Partial),
+	})
+	return []string{"barcode", "name", "grams", "found", "kcal", "protein", "fat", "carbs", "missing", "partial"}, rows
+}
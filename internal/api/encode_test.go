@@ -0,0 +1,316 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/korjavin/fastfooddb/internal/foodpb"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name, query, accept string
+		want                string
+		wantErr             bool
+	}{
+		{name: "no preference", want: formatJSON},
+		{name: "unsupported accept", accept: "text/html", want: formatJSON},
+		{name: "wildcard", accept: "*/*", want: formatJSON},
+		{name: "msgpack", accept: "application/msgpack", want: formatMsgpack},
+		{name: "msgpack alias", accept: "application/x-msgpack", want: formatMsgpack},
+		{name: "protobuf", accept: "application/x-protobuf", want: formatProtobuf},
+		{name: "csv with params", accept: "text/csv; charset=utf-8", want: formatCSV},
+		{name: "highest q wins", accept: "text/csv;q=0.5, application/msgpack;q=0.9, application/json;q=0.1", want: formatMsgpack},
+		{name: "first of equal q", accept: "text/csv, application/msgpack", want: formatCSV},
+		{name: "q=0 refuses", accept: "text/csv;q=0", want: formatJSON},
+		{name: "q=0 skipped for next", accept: "application/msgpack;q=0, text/csv;q=0.2", want: formatCSV},
+		{name: "bad q ignored", accept: "text/csv;q=high, application/msgpack;q=0.3", want: formatMsgpack},
+		{name: "format wins over accept", query: "format=csv", accept: "application/msgpack", want: formatCSV},
+		{name: "format json", query: "format=json", accept: "text/csv", want: formatJSON},
+		{name: "unknown format", query: "format=xml", wantErr: true},
+		{name: "unknown format despite accept", query: "format=yaml", accept: "text/csv", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/?"+tc.query, nil)
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+			got, err := negotiateFormat(r)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("got %q, want error", got)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Errorf("got %q, %v; want %q", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteResponse_UnknownFormat(t *testing.T) {
+	w := get(t, "/api/v1/food/barcode/3017620422003?format=xml", "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestProductTable(t *testing.T) {
+	kcal, protein := float32(539), float32(6.3)
+	nutella := productResponse{
+		Barcode: "3017620422003", Name: "Nutella", Brand: "Ferrero",
+		Kcal100g: &kcal, Protein: &protein, // fat and carbs missing
+		Alternates: []string{"3017620425035", "80177173"},
+		GS1Country: &gs1CountryResponse{Code: "FR", Name: "France", PrefixRange: "300–379"},
+		Quality:    []string{"kcal_inferred"},
+	}
+	bare := productResponse{Barcode: "2000000000001", Name: "Loose apples"}
+	withPortion := nutella
+	withPortion.Portion = &portionResponse{Amount: 20, Unit: "g", Grams: 20, Kcal: newNutrient(107.8), Protein: newNutrient(1.26)}
+
+	tests := []struct {
+		name string
+		v    csvResponse
+		want string
+	}{
+		{
+			name: "single product",
+			v:    nutella,
+			want: "barcode,name,brand,kcal100g,protein,fat,carbs,alternate_barcodes,gs1_country.code,gs1_country.name,gs1_country.prefix_range,quality_flags\n" +
+				"3017620422003,Nutella,Ferrero,539,6.3,,,3017620425035;80177173,FR,France,300–379,kcal_inferred\n",
+		},
+		{
+			name: "one row per search result",
+			v:    searchResponse{Results: []productResponse{nutella, bare}, Suggestion: "nutella"},
+			want: "barcode,name,brand,kcal100g,protein,fat,carbs,alternate_barcodes,gs1_country.code,gs1_country.name,gs1_country.prefix_range,quality_flags\n" +
+				"3017620422003,Nutella,Ferrero,539,6.3,,,3017620425035;80177173,FR,France,300–379,kcal_inferred\n" +
+				"2000000000001,Loose apples,,,,,,,,,,\n",
+		},
+		{
+			name: "empty result set",
+			v:    searchResponse{Results: []productResponse{}},
+			want: "barcode,name,brand,kcal100g,protein,fat,carbs,alternate_barcodes,gs1_country.code,gs1_country.name,gs1_country.prefix_range,quality_flags\n",
+		},
+		{
+			name: "portion columns",
+			v:    prefixResponse{Results: []productResponse{withPortion}, NextCursor: "3017620422004"},
+			want: "barcode,name,brand,kcal100g,protein,fat,carbs,alternate_barcodes,gs1_country.code,gs1_country.name,gs1_country.prefix_range,quality_flags," +
+				"portion.amount,portion.unit,portion.grams,portion.kcal,portion.kj,portion.protein,portion.fat,portion.carbs\n" +
+				"3017620422003,Nutella,Ferrero,539,6.3,,,3017620425035;80177173,FR,France,300–379,kcal_inferred,20,g,20,107.8,,1.26,,\n",
+		},
+		{
+			name: "suggestions",
+			v:    suggestResponse{Suggestions: []suggestionResponse{{Text: "nutella", Weight: 7}, {Text: "nutella, biscuits", Weight: 3}}},
+			want: "text,weight\nnutella,7\n\"nutella, biscuits\",3\n",
+		},
+		{
+			name: "calculate items",
+			v: calculateResponse{
+				Items: []calculateItemResponse{
+					{Barcode: "3017620422003", Name: "Nutella", Grams: 20, Found: true, Kcal: ptr(107.8), Protein: ptr(1.26), Fat: ptr(6.18), Carbs: ptr(11.5)},
+					{Barcode: "0000000000000", Grams: 50, Missing: []string{"kcal", "protein", "fat", "carbs"}},
+				},
+				Total: calculateTotal{Grams: 70, Kcal: 107.8, Protein: 1.26, Fat: 6.18, Carbs: 11.5}, Partial: true,
+			},
+			want: "barcode,name,grams,found,kcal,protein,fat,carbs,missing,partial\n" +
+				"3017620422003,Nutella,20,true,107.8,1.26,6.18,11.5,,\n" +
+				"0000000000000,,50,false,,,,,kcal;protein;fat;carbs,\n" +
+				"total,,70,,107.8,1.26,6.18,11.5,,true\n",
+		},
+		{
+			name: "complete calculation",
+			v: calculateResponse{
+				Items: []calculateItemResponse{{Barcode: "3017620422003", Name: "Nutella", Grams: 100, Found: true, Kcal: ptr(539), Protein: ptr(6.3), Fat: ptr(30.9), Carbs: ptr(57.5)}},
+				Total: calculateTotal{Grams: 100, Kcal: 539, Protein: 6.3, Fat: 30.9, Carbs: 57.5},
+			},
+			want: "barcode,name,grams,found,kcal,protein,fat,carbs,missing,partial\n" +
+				"3017620422003,Nutella,100,true,539,6.3,30.9,57.5,,\n" +
+				"total,,100,,539,6.3,30.9,57.5,,false\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := encodeCSV(tc.v.csvTable())
+			if err != nil {
+				t.Fatalf("encodeCSV: %v", err)
+			}
+			if string(body) != tc.want {
+				t.Errorf("got\n%s\nwant\n%s", body, tc.want)
+			}
+		})
+	}
+}
+
+// TestWriteResponse_MissingNutrient checks that Coca-Cola's NaN protein is
+// null, unset or empty in each format while its zero fat stays 0.
+func TestWriteResponse_MissingNutrient(t *testing.T) {
+	tests := []struct {
+		format      string
+		contentType string
+		check       func(t *testing.T, body []byte)
+	}{
+		{
+			format:      formatJSON,
+			contentType: "application/json",
+			check: func(t *testing.T, body []byte) {
+				var m map[string]any
+				if err := json.Unmarshal(body, &m); err != nil {
+					t.Fatal(err)
+				}
+				if v, ok := m["protein"]; !ok || v != nil {
+					t.Errorf("protein = %v (present %v), want null", v, ok)
+				}
+				if m["fat"] != 0.0 {
+					t.Errorf("fat = %v, want 0", m["fat"])
+				}
+			},
+		},
+		{
+			format:      formatMsgpack,
+			contentType: "application/msgpack",
+			check: func(t *testing.T, body []byte) {
+				var m map[string]any
+				if err := msgpack.Unmarshal(body, &m); err != nil {
+					t.Fatal(err)
+				}
+				if v, ok := m["protein"]; !ok || v != nil {
+					t.Errorf("protein = %v (present %v), want nil", v, ok)
+				}
+				// Every nutrient is a float32, whatever its value.
+				for _, k := range []string{"kcal100g", "fat", "carbs"} {
+					if _, ok := m[k].(float32); !ok {
+						t.Errorf("%s = %#v, want float32", k, m[k])
+					}
+				}
+			},
+		},
+		{
+			format:      formatProtobuf,
+			contentType: `application/x-protobuf; messageType="fastfooddb.v1.Product"`,
+			check: func(t *testing.T, body []byte) {
+				var p foodpb.Product
+				if err := proto.Unmarshal(body, &p); err != nil {
+					t.Fatal(err)
+				}
+				if p.Protein != nil {
+					t.Errorf("protein = %v, want unset", *p.Protein)
+				}
+				if p.Fat == nil || *p.Fat != 0 || p.GetKcal100G() != 42 || p.GetGs1Country().GetCode() == "" {
+					t.Errorf("product = %v", &p)
+				}
+			},
+		},
+		{
+			format:      formatCSV,
+			contentType: "text/csv; charset=utf-8",
+			check: func(t *testing.T, body []byte) {
+				records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(records) != 2 {
+					t.Fatalf("got %d records, want header and one row", len(records))
+				}
+				header, row := records[0], records[1]
+				if cell := row[slices.Index(header, "protein")]; cell != "" {
+					t.Errorf("protein = %q, want empty", cell)
+				}
+				if cell := row[slices.Index(header, "fat")]; cell != "0" {
+					t.Errorf("fat = %q, want 0", cell)
+				}
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			w := get(t, "/api/v1/food/barcode/5000112637922?format="+tc.format, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %q", w.Code, w.Body)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tc.contentType {
+				t.Errorf("Content-Type = %q, want %q", ct, tc.contentType)
+			}
+			tc.check(t, w.Body.Bytes())
+		})
+	}
+}
+
+func TestPortionMsgpack(t *testing.T) {
+	ps := portionSpec{Amount: 20, Unit: "g", Energy: "kcal"}
+	body, err := encodeMsgpack(ps.scale(testProducts["5000112637922"]))
+	if err != nil {
+		t.Fatalf("encodeMsgpack: %v", err)
+	}
+	var m map[string]any
+	if err := msgpack.Unmarshal(body, &m); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := m["protein"]; !ok || v != nil {
+		t.Errorf("protein = %v (present %v), want nil", v, ok)
+	}
+	if _, ok := m["kcal"].(float32); !ok {
+		t.Errorf("kcal = %#v, want float32", m["kcal"])
+	}
+	if v, ok := m["kj"]; ok {
+		t.Errorf("kj = %v, want omitted", v)
+	}
+}
+
+func TestWriteResponse_Protobuf(t *testing.T) {
+	w := get(t, "/api/v1/food/search?q=nut&grams=20", "application/x-protobuf")
+	if ct := w.Header().Get("Content-Type"); ct != `application/x-protobuf; messageType="fastfooddb.v1.SearchProductsResponse"` {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var resp foodpb.SearchProductsResponse
+	if err := proto.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(resp.GetResults()) != 1 || resp.GetSuggestion() != "nutella" {
+		t.Fatalf("response = %v", &resp)
+	}
+	portion := resp.GetResults()[0].GetPortion()
+	if portion.GetGrams() != 20 || portion.Kj != nil || portion.Kcal == nil {
+		t.Errorf("portion = %v, want 20 g in kcal only", portion)
+	}
+
+	// Responses without a foodpb message are refused rather than guessed.
+	for _, path := range []string{"/api/v1/food/suggest?q=nu", "/health"} {
+		if w := get(t, path, "application/x-protobuf"); w.Code != http.StatusNotAcceptable {
+			t.Errorf("%s: status = %d, want 406", path, w.Code)
+		}
+	}
+	if w := get(t, "/health", "text/csv"); w.Code != http.StatusNotAcceptable {
+		t.Errorf("/health as csv: status = %d, want 406", w.Code)
+	}
+}
+
+// get serves path through the handlers with the test products.
+func get(t *testing.T, path, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	h := &Handler{Products: testProducts, Searcher: testProducts, Suggester: testProducts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", h.Health)
+	mux.HandleFunc("GET /api/v1/food/barcode/{barcode}", h.FoodByBarcode)
+	mux.HandleFunc("GET /api/v1/food/search", h.FoodSearch)
+	mux.HandleFunc("GET /api/v1/food/suggest", h.FoodSuggest)
+
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func ptr(f float32) *float32 { return &f }
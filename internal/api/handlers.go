@@ -2,42 +2,89 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/korjavin/fastfooddb/internal/barcode"
 	"github.com/korjavin/fastfooddb/internal/metrics"
 	"github.com/korjavin/fastfooddb/internal/store"
 )
 
+// didYouMeanThreshold is the result count below which a search response
+// carries a spelling suggestion.
+const didYouMeanThreshold = 3
+
 // Handler holds dependencies for HTTP handlers.
 type Handler struct {
-	Store       *store.Store
-	Manifest    *store.Manifest
-	BarcodeHist *metrics.Histogram // nil-safe
-	SearchHist  *metrics.Histogram // nil-safe
+	Products      store.ProductLookup
+	Searcher      store.ProductSearcher
+	Suggester     store.NameSuggester
+	Stats         BackendStats // nil-safe
+	Manifest      *store.Manifest
+	BarcodeHist   *metrics.Histogram // nil-safe
+	SearchHist    *metrics.Histogram // nil-safe
+	SuggestHist   *metrics.Histogram // nil-safe
+	PrefixHist    *metrics.Histogram // nil-safe
+	CalculateHist *metrics.Histogram // nil-safe
+}
+
+// BackendStats exposes backend counters on /metrics. *store.Store
+// implements it.
+type BackendStats interface {
+	CacheStats() (store.CacheStats, bool)
+	CoalescedSearches() int64
 }
 
 // productResponse is the JSON shape returned for a single product.
 type productResponse struct {
-	Barcode  string   `json:"barcode"`
-	Name     string   `json:"name"`
-	Kcal100g *float32 `json:"kcal100g"`
-	Protein  *float32 `json:"protein"`
-	Fat      *float32 `json:"fat"`
-	Carbs    *float32 `json:"carbs"`
+	Barcode    string              `json:"barcode"`
+	Name       string              `json:"name"`
+	Brand      string              `json:"brand,omitempty"`
+	Kcal100g   *float32            `json:"kcal100g"`
+	Protein    *float32            `json:"protein"`
+	Fat        *float32            `json:"fat"`
+	Carbs      *float32            `json:"carbs"`
+	Alternates []string            `json:"alternate_barcodes,omitempty"`
+	GS1Country *gs1CountryResponse `json:"gs1_country,omitempty"`
+	Portion    *portionResponse    `json:"portion,omitempty"`
+	Quality    []string            `json:"quality_flags,omitempty"`
 }
 
-func toProductResponse(p store.Product) productResponse {
+// gs1CountryResponse describes where a barcode's company prefix was issued.
+type gs1CountryResponse struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	PrefixRange string `json:"prefix_range"`
+}
+
+// toProductResponse converts p; when ps is non-nil the response also
+// carries the nutrients scaled to that portion.
+func toProductResponse(p store.Product, ps *portionSpec) productResponse {
+	var gs1 *gs1CountryResponse
+	if c, ok := barcode.GS1Country(p.Barcode); ok {
+		gs1 = &gs1CountryResponse{Code: c.Code, Name: c.Country, PrefixRange: c.Range()}
+	}
+	var portion *portionResponse
+	if ps != nil {
+		portion = ps.scale(p)
+	}
 	return productResponse{
-		Barcode:  p.Barcode,
-		Name:     p.Name,
-		Kcal100g: nanToNil(p.Kcal100g),
-		Protein:  nanToNil(p.Protein),
-		Fat:      nanToNil(p.Fat),
-		Carbs:    nanToNil(p.Carbs),
+		Barcode:    p.Barcode,
+		Name:       p.Name,
+		Brand:      p.Brand,
+		Kcal100g:   nanToNil(p.Kcal100g),
+		Protein:    nanToNil(p.Protein),
+		Fat:        nanToNil(p.Fat),
+		Carbs:      nanToNil(p.Carbs),
+		Alternates: p.Alternates,
+		GS1Country: gs1,
+		Portion:    portion,
+		Quality:    p.Quality.Names(),
 	}
 }
 
@@ -56,7 +103,7 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 		resp["schema_version"] = h.Manifest.SchemaVersion
 		resp["build_time"] = h.Manifest.BuildTime
 	}
-	writeJSON(w, http.StatusOK, resp)
+	writeResponse(w, r, http.StatusOK, resp)
 }
 
 // FoodByBarcode looks up nutritional info by product barcode.
@@ -64,8 +111,14 @@ func (h *Handler) FoodByBarcode(w http.ResponseWriter, r *http.Request) {
 	barcode := r.PathValue("barcode")
 	slog.Info("food by barcode request", "barcode", barcode)
 
+	portion, err := parsePortion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	t0 := time.Now()
-	p, found, err := h.Store.Get(barcode)
+	p, found, err := h.Products.Get(barcode)
 	if h.BarcodeHist != nil {
 		h.BarcodeHist.Observe(time.Since(t0))
 	}
@@ -78,7 +131,76 @@ func (h *Handler) FoodByBarcode(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "not found", http.StatusNotFound)
 		return
 	}
-	writeJSON(w, http.StatusOK, toProductResponse(p))
+	writeResponse(w, r, http.StatusOK, toProductResponse(p, portion))
+}
+
+// prefixResponse is a page of a barcode prefix listing. NextCursor is empty
+// on the last page.
+type prefixResponse struct {
+	Results    []productResponse `json:"results"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// FoodByBarcodePrefix lists products whose barcode starts with a prefix,
+// e.g. a GS1 company prefix, in barcode order with cursor pagination.
+func (h *Handler) FoodByBarcodePrefix(w http.ResponseWriter, r *http.Request) {
+	prefix := r.PathValue("prefix")
+	if !isDigits(prefix) {
+		http.Error(w, "prefix must be digits only", http.StatusBadRequest)
+		return
+	}
+	cursor := r.URL.Query().Get("cursor")
+	if cursor != "" && !strings.HasPrefix(cursor, prefix) {
+		http.Error(w, "cursor does not match prefix", http.StatusBadRequest)
+		return
+	}
+
+	limit := 20
+	if ls := r.URL.Query().Get("limit"); ls != "" {
+		if n, err := strconv.Atoi(ls); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	t0 := time.Now()
+	products, next, err := h.Products.ScanPrefix(prefix, cursor, limit)
+	if h.PrefixHist != nil {
+		h.PrefixHist.Observe(time.Since(t0))
+	}
+	if err != nil {
+		slog.Error("barcode prefix scan failed", "prefix", prefix, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]productResponse, len(products))
+	for i, p := range products {
+		results[i] = toProductResponse(p, nil)
+	}
+	writeResponse(w, r, http.StatusOK, prefixResponse{Results: results, NextCursor: next})
+}
+
+// isDigits reports whether s is non-empty and all ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// searchResponse carries search results and, for searches with few
+// results, a spelling suggestion.
+type searchResponse struct {
+	Results    []productResponse `json:"results"`
+	Suggestion string            `json:"suggestion,omitempty"`
 }
 
 // FoodSearch searches for foods by name.
@@ -99,10 +221,28 @@ func (h *Handler) FoodSearch(w http.ResponseWriter, r *http.Request) {
 		limit = 100
 	}
 
-	slog.Info("food search request", "query", q, "limit", limit)
+	portion, err := parsePortion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := store.SearchOptions{
+		Limit:      limit,
+		Lang:       r.URL.Query().Get("lang"),
+		GS1Country: r.URL.Query().Get("gs1_country"),
+	}
+	if cs := r.URL.Query().Get("collapse"); cs != "" {
+		opts.Collapse, _ = strconv.ParseBool(cs)
+	}
+	if eq := r.URL.Query().Get("exclude_low_quality"); eq != "" {
+		opts.ExcludeLowQuality, _ = strconv.ParseBool(eq)
+	}
+
+	slog.Info("food search request", "query", q, "limit", limit, "lang", opts.Lang, "collapse", opts.Collapse)
 
 	t0 := time.Now()
-	products, err := h.Store.Search(q, limit)
+	products, err := h.Searcher.SearchWith(q, opts)
 	if h.SearchHist != nil {
 		h.SearchHist.Observe(time.Since(t0))
 	}
@@ -114,19 +254,83 @@ func (h *Handler) FoodSearch(w http.ResponseWriter, r *http.Request) {
 
 	results := make([]productResponse, len(products))
 	for i, p := range products {
-		results[i] = toProductResponse(p)
+		results[i] = toProductResponse(p, portion)
+	}
+	resp := searchResponse{Results: results}
+	if len(products) < didYouMeanThreshold {
+		if suggestion, err := h.Suggester.DidYouMean(q); err != nil {
+			slog.Warn("did-you-mean failed", "query", q, "error", err)
+		} else {
+			resp.Suggestion = suggestion
+		}
+	}
+	writeResponse(w, r, http.StatusOK, resp)
+}
+
+// suggestionResponse is the JSON shape of a single autocomplete completion.
+type suggestionResponse struct {
+	Text   string `json:"text"`
+	Weight uint64 `json:"weight"`
+}
+
+type suggestResponse struct {
+	Suggestions []suggestionResponse `json:"suggestions"`
+}
+
+// FoodSuggest returns top-K name completions for typeahead from the FST
+// suggest index. It does not run a Bleve query.
+func (h *Handler) FoodSuggest(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		http.Error(w, "missing query parameter 'q'", http.StatusBadRequest)
+		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"results": results})
+
+	limit := store.SuggestMaxK
+	if ls := r.URL.Query().Get("limit"); ls != "" {
+		if n, err := strconv.Atoi(ls); err == nil && n > 0 && n < limit {
+			limit = n
+		}
+	}
+
+	t0 := time.Now()
+	suggestions, err := h.Suggester.Suggest(q, limit)
+	if h.SuggestHist != nil {
+		h.SuggestHist.Observe(time.Since(t0))
+	}
+	if errors.Is(err, store.ErrSuggestUnavailable) {
+		http.Error(w, "suggest index not available", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		slog.Error("suggest failed", "query", q, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]suggestionResponse, len(suggestions))
+	for i, sg := range suggestions {
+		results[i] = suggestionResponse{Text: sg.Text, Weight: sg.Weight}
+	}
+	writeResponse(w, r, http.StatusOK, suggestResponse{Suggestions: results})
 }
 
 // Metrics returns an http.HandlerFunc that emits p50/p95/p99 latency snapshots.
 func (h *Handler) Metrics(reg *metrics.Registry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if reg == nil {
-			writeJSON(w, http.StatusOK, map[string]any{})
-			return
+		out := make(map[string]any)
+		if reg != nil {
+			for name, snap := range reg.Snapshot() {
+				out[name] = snap
+			}
+		}
+		if h.Stats != nil {
+			if st, ok := h.Stats.CacheStats(); ok {
+				out["cache"] = st
+			}
+			out["search_coalesced"] = h.Stats.CoalescedSearches()
 		}
-		writeJSON(w, http.StatusOK, reg.Snapshot())
+		writeResponse(w, r, http.StatusOK, out)
 	}
 }
 
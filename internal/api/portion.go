@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/korjavin/fastfooddb/internal/store"
+)
+
+const (
+	gramsPerOunce = 28.349523125
+	kjPerKcal     = 4.184
+	maxPortion    = 100_000 // grams
+)
+
+// portionSpec is a requested serving size and energy unit, parsed from the
+// grams= or amount=, unit= and energy= query parameters.
+type portionSpec struct {
+	Amount float64 // in Unit
+	Unit   string  // "g" or "oz"
+	Energy string  // "kcal", "kj" or "both"
+}
+
+// grams returns the portion size in grams.
+func (ps portionSpec) grams() float64 {
+	if ps.Unit == "oz" {
+		return ps.Amount * gramsPerOunce
+	}
+	return ps.Amount
+}
+
+// parsePortion reads the portion parameters. grams= is always in grams
+// and amount= is in unit=, so grams= cannot be combined with amount= or a
+// unit other than g. It returns nil when none are set, so responses keep
+// their plain per-100g shape.
+func parsePortion(r *http.Request) (*portionSpec, error) {
+	q := r.URL.Query()
+	gramsStr, amountStr, unit, energy := q.Get("grams"), q.Get("amount"), q.Get("unit"), q.Get("energy")
+	if gramsStr == "" && amountStr == "" && unit == "" && energy == "" {
+		return nil, nil
+	}
+
+	ps := &portionSpec{Amount: 100, Unit: "g", Energy: "kcal"}
+	switch unit {
+	case "", "g":
+	case "oz":
+		ps.Unit = "oz"
+	case "ml":
+		// Volumes would need a density, which the dataset does not carry.
+		return nil, fmt.Errorf("unit ml is not supported; give the portion in g or oz")
+	default:
+		return nil, fmt.Errorf("unit must be g or oz")
+	}
+	switch energy {
+	case "", "kcal":
+	case "kj", "both":
+		ps.Energy = energy
+	default:
+		return nil, fmt.Errorf("energy must be kcal, kj or both")
+	}
+
+	var err error
+	switch {
+	case gramsStr != "" && amountStr != "":
+		return nil, fmt.Errorf("give either grams or amount, not both")
+	case gramsStr != "" && ps.Unit != "g":
+		return nil, fmt.Errorf("grams is always in g; use amount with unit=%s", ps.Unit)
+	case gramsStr != "":
+		ps.Amount, err = parsePositive("grams", gramsStr)
+	case amountStr != "":
+		ps.Amount, err = parsePositive("amount", amountStr)
+	case ps.Unit == "oz":
+		ps.Amount = 1
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ps.grams() > maxPortion {
+		return nil, fmt.Errorf("portion must not exceed %d g", maxPortion)
+	}
+	return ps, nil
+}
+
+func parsePositive(name, s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || !(v > 0) {
+		return 0, fmt.Errorf("%s must be a positive number", name)
+	}
+	return v, nil
+}
+
+// portionResponse holds nutrients scaled to a portion. Amount and Unit
+// echo the request; Grams is the basis the values were scaled to. msgpack
+// has no omitzero, but its omitempty consults IsZero.
+type portionResponse struct {
+	Amount  float64  `json:"amount"`
+	Unit    string   `json:"unit"`
+	Grams   float64  `json:"grams"`
+	Kcal    nutrient `json:"kcal,omitzero" msgpack:"kcal,omitempty"`
+	KJ      nutrient `json:"kj,omitzero" msgpack:"kj,omitempty"`
+	Protein nutrient `json:"protein"`
+	Fat     nutrient `json:"fat"`
+	Carbs   nutrient `json:"carbs"`
+}
+
+// nutrient is a requested value that encodes as JSON null when missing
+// (NaN). The zero value means "not requested" and is dropped by omitzero.
+type nutrient struct {
+	set bool
+	v   float32
+}
+
+func newNutrient(v float32) nutrient { return nutrient{set: true, v: v} }
+
+func (n nutrient) IsZero() bool { return !n.set }
+
+func (n nutrient) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(n.v)) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.v)
+}
+
+func (n nutrient) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if v := n.ptr(); v != nil {
+		return enc.EncodeFloat32(*v)
+	}
+	return enc.EncodeNil()
+}
+
+// ptr returns the value, or nil when it was not requested or is missing.
+func (n nutrient) ptr() *float32 {
+	if !n.set {
+		return nil
+	}
+	return nanToNil(n.v)
+}
+
+// scale returns p's per-100g values scaled to the portion. NaN stays NaN.
+func (ps portionSpec) scale(p store.Product) *portionResponse {
+	g := ps.grams()
+	scaled := func(per100g float32, k float64) nutrient {
+		return newNutrient(float32(float64(per100g) * k * g / 100))
+	}
+	out := &portionResponse{
+		Amount:  ps.Amount,
+		Unit:    ps.Unit,
+		Grams:   g,
+		Protein: scaled(p.Protein, 1),
+		Fat:     scaled(p.Fat, 1),
+		Carbs:   scaled(p.Carbs, 1),
+	}
+	if ps.Energy != "kj" {
+		out.Kcal = scaled(p.Kcal100g, 1)
+	}
+	if ps.Energy != "kcal" {
+		out.KJ = scaled(p.Kcal100g, kjPerKcal)
+	}
+	return out
+}
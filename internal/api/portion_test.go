@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/korjavin/fastfooddb/internal/store"
+)
+
+func TestParsePortion(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    *portionSpec
+		wantErr bool
+	}{
+		{query: "", want: nil},
+		{query: "grams=30", want: &portionSpec{Amount: 30, Unit: "g", Energy: "kcal"}},
+		{query: "grams=30&unit=g", want: &portionSpec{Amount: 30, Unit: "g", Energy: "kcal"}},
+		{query: "amount=30", want: &portionSpec{Amount: 30, Unit: "g", Energy: "kcal"}},
+		{query: "amount=2&unit=oz", want: &portionSpec{Amount: 2, Unit: "oz", Energy: "kcal"}},
+		{query: "unit=oz", want: &portionSpec{Amount: 1, Unit: "oz", Energy: "kcal"}},
+		{query: "energy=both", want: &portionSpec{Amount: 100, Unit: "g", Energy: "both"}},
+		{query: "grams=2&unit=oz", wantErr: true},
+		{query: "grams=30&amount=30", wantErr: true},
+		{query: "amount=250&unit=ml", wantErr: true},
+		{query: "unit=lb", wantErr: true},
+		{query: "energy=cal", wantErr: true},
+		{query: "grams=0", wantErr: true},
+		{query: "grams=-5", wantErr: true},
+		{query: "grams=NaN", wantErr: true},
+		{query: "amount=abc", wantErr: true},
+		{query: "grams=100001", wantErr: true},
+		{query: "amount=3528&unit=oz", wantErr: true}, // 100 014 g
+		{query: "grams=100000", want: &portionSpec{Amount: maxPortion, Unit: "g", Energy: "kcal"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			got, err := parsePortion(httptest.NewRequest("GET", "/?"+tc.query, nil))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("got %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPortionScale(t *testing.T) {
+	nutella := store.Product{Kcal100g: 539, Protein: 6.3, Fat: 30.9, Carbs: float32(math.NaN())}
+
+	tests := []struct {
+		name string
+		ps   portionSpec
+		want string
+	}{
+		{
+			name: "grams",
+			ps:   portionSpec{Amount: 20, Unit: "g", Energy: "kcal"},
+			want: `{"amount":20,"unit":"g","grams":20,"kcal":107.8,"protein":1.26,"fat":6.18,"carbs":null}`,
+		},
+		{
+			name: "ounces",
+			ps:   portionSpec{Amount: 1, Unit: "oz", Energy: "kcal"},
+			want: `{"amount":1,"unit":"oz","grams":28.349523125,"kcal":152.80393,"protein":1.786020,"fat":8.760002,"carbs":null}`,
+		},
+		{
+			name: "kj",
+			ps:   portionSpec{Amount: 100, Unit: "g", Energy: "kj"},
+			want: `{"amount":100,"unit":"g","grams":100,"kj":2255.176,"protein":6.3,"fat":30.9,"carbs":null}`,
+		},
+		{
+			name: "both",
+			ps:   portionSpec{Amount: 50, Unit: "g", Energy: "both"},
+			want: `{"amount":50,"unit":"g","grams":50,"kcal":269.5,"kj":1127.588,"protein":3.15,"fat":15.45,"carbs":null}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			js, err := json.Marshal(tc.ps.scale(nutella))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if !jsonNumbersClose(t, string(js), tc.want) {
+				t.Errorf("got  %s\nwant %s", js, tc.want)
+			}
+		})
+	}
+}
+
+func TestFoodByBarcode_Portion(t *testing.T) {
+	h := &Handler{Products: testProducts}
+	tests := []struct {
+		query string
+		want  *portionResponse
+	}{
+		{query: "", want: nil},
+		{query: "?amount=2&unit=oz", want: &portionResponse{Amount: 2, Unit: "oz", Grams: 2 * gramsPerOunce}},
+		{query: "?grams=30", want: &portionResponse{Amount: 30, Unit: "g", Grams: 30}},
+	}
+	for _, tc := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/food/barcode/3017620422003"+tc.query, nil)
+		r.SetPathValue("barcode", "3017620422003")
+		w := httptest.NewRecorder()
+		h.FoodByBarcode(w, r)
+
+		var resp struct {
+			Portion *struct {
+				Amount float64 `json:"amount"`
+				Unit   string  `json:"unit"`
+				Grams  float64 `json:"grams"`
+			} `json:"portion"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%q: decode %q: %v", tc.query, w.Body, err)
+		}
+		switch {
+		case tc.want == nil && resp.Portion != nil:
+			t.Errorf("%q: portion = %+v, want none", tc.query, resp.Portion)
+		case tc.want != nil && (resp.Portion == nil || resp.Portion.Amount != tc.want.Amount ||
+			resp.Portion.Unit != tc.want.Unit || resp.Portion.Grams != tc.want.Grams):
+			t.Errorf("%q: portion = %+v, want amount %v %s = %v g", tc.query, resp.Portion, tc.want.Amount, tc.want.Unit, tc.want.Grams)
+		}
+	}
+}
+
+// jsonNumbersClose reports whether two JSON objects have the same keys and
+// values, with numbers equal to within 1e-4 relative error.
+func jsonNumbersClose(t *testing.T, got, want string) bool {
+	t.Helper()
+	var g, w map[string]any
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		t.Fatalf("unmarshal %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("unmarshal %s: %v", want, err)
+	}
+	if len(g) != len(w) {
+		return false
+	}
+	for k, wv := range w {
+		gv, ok := g[k]
+		if !ok {
+			return false
+		}
+		wf, wIsNum := wv.(float64)
+		gf, gIsNum := gv.(float64)
+		switch {
+		case wIsNum && gIsNum:
+			if math.Abs(gf-wf) > 1e-4*math.Abs(wf) {
+				return false
+			}
+		case gv != wv:
+			return false
+		}
+	}
+	return true
+}
@@ -8,12 +8,17 @@ import (
 	"github.com/korjavin/fastfooddb/internal/store"
 )
 
-// RegisterRoutes registers all HTTP routes on the given mux.
-func RegisterRoutes(mux *http.ServeMux, apiKeys []string, s *store.Store, m *store.Manifest, reg *metrics.Registry) {
-	h := &Handler{Store: s, Manifest: m}
+// RegisterRoutes registers all HTTP routes on the given mux. Barcode
+// lookups, suggestions and stats come from s; searches go to searcher, which
+// is s itself for the Bleve backend.
+func RegisterRoutes(mux *http.ServeMux, apiKeys []string, s *store.Store, searcher store.ProductSearcher, m *store.Manifest, reg *metrics.Registry) {
+	h := &Handler{Products: s, Searcher: searcher, Suggester: s, Stats: s, Manifest: m}
 	if reg != nil {
 		h.BarcodeHist = reg.Register("barcode_get", metrics.BucketsBarcode)
 		h.SearchHist = reg.Register("search", metrics.BucketsSearch)
+		h.SuggestHist = reg.Register("suggest", metrics.BucketsBarcode)
+		h.PrefixHist = reg.Register("barcode_prefix", metrics.BucketsBarcode)
+		h.CalculateHist = reg.Register("calculate", metrics.BucketsSearch)
 	}
 	protected := auth.APIKeyMiddleware(apiKeys)
 
@@ -23,5 +28,8 @@ func RegisterRoutes(mux *http.ServeMux, apiKeys []string, s *store.Store, m *sto
 
 	// Protected — require X-API-Key header (or api_key query param)
 	mux.Handle("GET /api/v1/food/barcode/{barcode}", protected(http.HandlerFunc(h.FoodByBarcode)))
+	mux.Handle("GET /api/v1/food/barcode-prefix/{prefix}", protected(http.HandlerFunc(h.FoodByBarcodePrefix)))
 	mux.Handle("GET /api/v1/food/search", protected(http.HandlerFunc(h.FoodSearch)))
+	mux.Handle("POST /api/v1/food/calculate", protected(http.HandlerFunc(h.FoodCalculate)))
+	mux.Handle("GET /api/v1/food/suggest", protected(http.HandlerFunc(h.FoodSuggest)))
 }
@@ -18,28 +18,41 @@ func ParseAPIKeys(raw string) []string {
 	return keys
 }
 
+// KeySet is a set of valid API keys.
+type KeySet map[string]struct{}
+
+// NewKeySet builds a KeySet from validKeys.
+func NewKeySet(validKeys []string) KeySet {
+	keySet := make(KeySet, len(validKeys))
+	for _, k := range validKeys {
+		keySet[k] = struct{}{}
+	}
+	return keySet
+}
+
+// Allows reports whether key is valid. An empty set allows every key.
+func (ks KeySet) Allows(key string) bool {
+	if len(ks) == 0 {
+		return true
+	}
+	_, ok := ks[key]
+	return ok
+}
+
 // APIKeyMiddleware returns a middleware that validates the X-API-Key header.
 // Also accepts api_key as a query parameter as a fallback.
 // If no keys are configured, all requests are allowed through.
 func APIKeyMiddleware(validKeys []string) func(http.Handler) http.Handler {
-	keySet := make(map[string]struct{}, len(validKeys))
-	for _, k := range validKeys {
-		keySet[k] = struct{}{}
-	}
+	keySet := NewKeySet(validKeys)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if len(keySet) == 0 {
-				next.ServeHTTP(w, r)
-				return
-			}
-
 			key := r.Header.Get("X-API-Key")
 			if key == "" {
 				key = r.URL.Query().Get("api_key")
 			}
 
-			if _, ok := keySet[key]; !ok {
+			if !keySet.Allows(key) {
 				http.Error(w, "unauthorized", http.StatusUnauthorized)
 				return
 			}
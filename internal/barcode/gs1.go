@@ -0,0 +1,204 @@
+// Package barcode derives metadata from product barcodes.
+package barcode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GS1Prefix is a range of GS1 prefixes assigned to one member organisation.
+// The prefix identifies where the company prefix was registered, not where
+// the product was made.
+type GS1Prefix struct {
+	Low, High int    // inclusive 3-digit prefix range
+	Code      string // ISO 3166-1 alpha-2 code of the member organisation
+	Country   string // display name
+}
+
+// Range formats the prefix range, e.g. "400–440" or "729".
+func (p GS1Prefix) Range() string {
+	if p.Low == p.High {
+		return fmt.Sprintf("%03d", p.Low)
+	}
+	return fmt.Sprintf("%03d–%03d", p.Low, p.High)
+}
+
+// Codes returns every ISO code the prefix range serves: Code, then the other
+// members of a shared range, e.g. BE and LU for 540–549.
+func (p GS1Prefix) Codes() []string {
+	return append([]string{p.Code}, gs1SharedCodes[p.Code]...)
+}
+
+// gs1SharedCodes lists the other countries served by a range that is
+// assigned under the code of its member organisation.
+var gs1SharedCodes = map[string][]string{
+	"BE": {"LU"},
+}
+
+// gs1Prefixes lists GS1 member organisation prefixes, sorted by Low.
+// Gaps are unassigned or reserved for restricted circulation, coupons,
+// ISSN/ISBN and GS1 Global Office use, and have no country.
+var gs1Prefixes = []GS1Prefix{
+	{0, 19, "US", "United States"},
+	{30, 39, "US", "United States"},
+	{60, 139, "US", "United States"},
+	{300, 379, "FR", "France"},
+	{380, 380, "BG", "Bulgaria"},
+	{383, 383, "SI", "Slovenia"},
+	{385, 385, "HR", "Croatia"},
+	{387, 387, "BA", "Bosnia and Herzegovina"},
+	{389, 389, "ME", "Montenegro"},
+	{390, 390, "XK", "Kosovo"},
+	{400, 440, "DE", "Germany"},
+	{450, 459, "JP", "Japan"},
+	{460, 469, "RU", "Russia"},
+	{470, 470, "KG", "Kyrgyzstan"},
+	{471, 471, "TW", "Taiwan"},
+	{474, 474, "EE", "Estonia"},
+	{475, 475, "LV", "Latvia"},
+	{476, 476, "AZ", "Azerbaijan"},
+	{477, 477, "LT", "Lithuania"},
+	{478, 478, "UZ", "Uzbekistan"},
+	{479, 479, "LK", "Sri Lanka"},
+	{480, 480, "PH", "Philippines"},
+	{481, 481, "BY", "Belarus"},
+	{482, 482, "UA", "Ukraine"},
+	{483, 483, "TM", "Turkmenistan"},
+	{484, 484, "MD", "Moldova"},
+	{485, 485, "AM", "Armenia"},
+	{486, 486, "GE", "Georgia"},
+	{487, 487, "KZ", "Kazakhstan"},
+	{488, 488, "TJ", "Tajikistan"},
+	{489, 489, "HK", "Hong Kong"},
+	{490, 499, "JP", "Japan"},
+	{500, 509, "GB", "United Kingdom"},
+	{520, 521, "GR", "Greece"},
+	{528, 528, "LB", "Lebanon"},
+	{529, 529, "CY", "Cyprus"},
+	{530, 530, "AL", "Albania"},
+	{531, 531, "MK", "North Macedonia"},
+	{535, 535, "MT", "Malta"},
+	{539, 539, "IE", "Ireland"},
+	{540, 549, "BE", "Belgium & Luxembourg"},
+	{560, 560, "PT", "Portugal"},
+	{569, 569, "IS", "Iceland"},
+	{570, 579, "DK", "Denmark"},
+	{590, 590, "PL", "Poland"},
+	{594, 594, "RO", "Romania"},
+	{599, 599, "HU", "Hungary"},
+	{600, 601, "ZA", "South Africa"},
+	{603, 603, "GH", "Ghana"},
+	{604, 604, "SN", "Senegal"},
+	{605, 605, "UG", "Uganda"},
+	{606, 606, "AO", "Angola"},
+	{607, 607, "OM", "Oman"},
+	{608, 608, "BH", "Bahrain"},
+	{609, 609, "MU", "Mauritius"},
+	{611, 611, "MA", "Morocco"},
+	{613, 613, "DZ", "Algeria"},
+	{615, 615, "NG", "Nigeria"},
+	{616, 616, "KE", "Kenya"},
+	{617, 617, "CM", "Cameroon"},
+	{618, 618, "CI", "Côte d'Ivoire"},
+	{619, 619, "TN", "Tunisia"},
+	{620, 620, "TZ", "Tanzania"},
+	{621, 621, "SY", "Syria"},
+	{622, 622, "EG", "Egypt"},
+	{623, 623, "BN", "Brunei"},
+	{624, 624, "LY", "Libya"},
+	{625, 625, "JO", "Jordan"},
+	{626, 626, "IR", "Iran"},
+	{627, 627, "KW", "Kuwait"},
+	{628, 628, "SA", "Saudi Arabia"},
+	{629, 629, "AE", "United Arab Emirates"},
+	{630, 630, "QA", "Qatar"},
+	{631, 631, "NA", "Namibia"},
+	{640, 649, "FI", "Finland"},
+	{690, 699, "CN", "China"},
+	{700, 709, "NO", "Norway"},
+	{729, 729, "IL", "Israel"},
+	{730, 739, "SE", "Sweden"},
+	{740, 740, "GT", "Guatemala"},
+	{741, 741, "SV", "El Salvador"},
+	{742, 742, "HN", "Honduras"},
+	{743, 743, "NI", "Nicaragua"},
+	{744, 744, "CR", "Costa Rica"},
+	{745, 745, "PA", "Panama"},
+	{746, 746, "DO", "Dominican Republic"},
+	{750, 750, "MX", "Mexico"},
+	{754, 755, "CA", "Canada"},
+	{759, 759, "VE", "Venezuela"},
+	{760, 769, "CH", "Switzerland"},
+	{770, 771, "CO", "Colombia"},
+	{773, 773, "UY", "Uruguay"},
+	{775, 775, "PE", "Peru"},
+	{777, 777, "BO", "Bolivia"},
+	{778, 779, "AR", "Argentina"},
+	{780, 780, "CL", "Chile"},
+	{784, 784, "PY", "Paraguay"},
+	{786, 786, "EC", "Ecuador"},
+	{789, 790, "BR", "Brazil"},
+	{800, 839, "IT", "Italy"},
+	{840, 849, "ES", "Spain"},
+	{850, 850, "CU", "Cuba"},
+	{858, 858, "SK", "Slovakia"},
+	{859, 859, "CZ", "Czechia"},
+	{860, 860, "RS", "Serbia"},
+	{865, 865, "MN", "Mongolia"},
+	{867, 867, "KP", "North Korea"},
+	{868, 869, "TR", "Turkey"},
+	{870, 879, "NL", "Netherlands"},
+	{880, 881, "KR", "South Korea"},
+	{883, 883, "MM", "Myanmar"},
+	{884, 884, "KH", "Cambodia"},
+	{885, 885, "TH", "Thailand"},
+	{888, 888, "SG", "Singapore"},
+	{890, 890, "IN", "India"},
+	{893, 893, "VN", "Vietnam"},
+	{894, 894, "BD", "Bangladesh"},
+	{896, 896, "PK", "Pakistan"},
+	{899, 899, "ID", "Indonesia"},
+	{900, 919, "AT", "Austria"},
+	{930, 939, "AU", "Australia"},
+	{940, 949, "NZ", "New Zealand"},
+	{955, 955, "MY", "Malaysia"},
+	{958, 958, "MO", "Macau"},
+}
+
+// GS1Country returns the GS1 member organisation that issued the company
+// prefix of a GTIN-8, UPC-A (GTIN-12), EAN-13 or GTIN-14 barcode.
+// ok is false for other lengths, non-digit input and prefixes without a
+// country (restricted circulation, coupons, ISBN/ISSN, unassigned).
+func GS1Country(code string) (p GS1Prefix, ok bool) {
+	prefix, ok := gs1Digits(code)
+	if !ok {
+		return GS1Prefix{}, false
+	}
+	i := sort.Search(len(gs1Prefixes), func(i int) bool { return gs1Prefixes[i].High >= prefix })
+	if i < len(gs1Prefixes) && gs1Prefixes[i].Low <= prefix {
+		return gs1Prefixes[i], true
+	}
+	return GS1Prefix{}, false
+}
+
+// gs1Digits extracts the 3-digit GS1 prefix, normalising UPC-A to EAN-13 by
+// its implicit leading zero and dropping the GTIN-14 packaging indicator.
+func gs1Digits(code string) (int, bool) {
+	code = strings.TrimSpace(code)
+	for i := 0; i < len(code); i++ {
+		if code[i] < '0' || code[i] > '9' {
+			return 0, false
+		}
+	}
+	switch len(code) {
+	case 8, 13:
+	case 12:
+		code = "0" + code
+	case 14:
+		code = code[1:]
+	default:
+		return 0, false
+	}
+	return int(code[0]-'0')*100 + int(code[1]-'0')*10 + int(code[2]-'0'), true
+}
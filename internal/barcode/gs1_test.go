@@ -0,0 +1,59 @@
+package barcode
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGS1Prefixes_Sorted(t *testing.T) {
+	for i, p := range gs1Prefixes {
+		if p.Low > p.High {
+			t.Errorf("entry %d: Low %d > High %d", i, p.Low, p.High)
+		}
+		if i > 0 && p.Low <= gs1Prefixes[i-1].High {
+			t.Errorf("entry %d (%s) overlaps or is out of order", i, p.Range())
+		}
+	}
+}
+
+func TestGS1Country(t *testing.T) {
+	tests := []struct {
+		code      string
+		wantCode  string
+		wantRange string
+	}{
+		{"4000417025005", "DE", "400–440"},
+		{"4400000000000", "DE", "400–440"},
+		{"3017620422003", "FR", "300–379"},
+		{"5000112637922", "GB", "500–509"},
+		{"7290000000000", "IL", "729"},
+		{"049000028911", "US", "000–019"},   // UPC-A, implicit leading 0
+		{"15000112637922", "GB", "500–509"}, // GTIN-14, indicator digit dropped
+		{"40170725", "DE", "400–440"},       // EAN-8
+		{"9780306406157", "", ""},           // ISBN
+		{"2000000000001", "", ""},           // restricted circulation
+		{"12345", "", ""},                   // unsupported length
+		{"40004170250AB", "", ""},           // non-digit
+	}
+	for _, tc := range tests {
+		p, ok := GS1Country(tc.code)
+		if ok != (tc.wantCode != "") || p.Code != tc.wantCode || (ok && p.Range() != tc.wantRange) {
+			t.Errorf("GS1Country(%q) = %+v (%s), %v; want %s %s", tc.code, p, p.Range(), ok, tc.wantCode, tc.wantRange)
+		}
+	}
+}
+
+func TestGS1Prefix_Codes(t *testing.T) {
+	for _, tc := range []struct {
+		code string
+		want string
+	}{
+		{"5400141000000", "BE,LU"},
+		{"4000417025005", "DE"},
+	} {
+		p, ok := GS1Country(tc.code)
+		if got := strings.Join(p.Codes(), ","); !ok || got != tc.want {
+			t.Errorf("GS1Country(%q).Codes() = %s, %v; want %s", tc.code, got, ok, tc.want)
+		}
+	}
+}
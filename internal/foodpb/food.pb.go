@@ -0,0 +1,915 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: food.proto
+
+package foodpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Product mirrors the JSON product response. Nutrients are per 100 g and
+// unset when the source has no value for them.
+type Product struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Barcode           string                 `protobuf:"bytes,1,opt,name=barcode,proto3" json:"barcode,omitempty"`
+	Name              string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Brand             string                 `protobuf:"bytes,3,opt,name=brand,proto3" json:"brand,omitempty"`
+	Kcal100G          *float32               `protobuf:"fixed32,4,opt,name=kcal100g,proto3,oneof" json:"kcal100g,omitempty"`
+	Protein           *float32               `protobuf:"fixed32,5,opt,name=protein,proto3,oneof" json:"protein,omitempty"`
+	Fat               *float32               `protobuf:"fixed32,6,opt,name=fat,proto3,oneof" json:"fat,omitempty"`
+	Carbs             *float32               `protobuf:"fixed32,7,opt,name=carbs,proto3,oneof" json:"carbs,omitempty"`
+	AlternateBarcodes []string               `protobuf:"bytes,8,rep,name=alternate_barcodes,json=alternateBarcodes,proto3" json:"alternate_barcodes,omitempty"`
+	Gs1Country        *GS1Country            `protobuf:"bytes,9,opt,name=gs1_country,json=gs1Country,proto3" json:"gs1_country,omitempty"`
+	QualityFlags      []string               `protobuf:"bytes,10,rep,name=quality_flags,json=qualityFlags,proto3" json:"quality_flags,omitempty"`
+	// portion is set only by the HTTP API, when the request asked for one.
+	Portion       *Portion `protobuf:"bytes,11,opt,name=portion,proto3" json:"portion,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Product) Reset() {
+	*x = Product{}
+	mi := &file_food_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Product) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Product) ProtoMessage() {}
+
+func (x *Product) ProtoReflect() protoreflect.Message {
+	mi := &file_food_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Product.ProtoReflect.Descriptor instead.
+func (*Product) Descriptor() ([]byte, []int) {
+	return file_food_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Product) GetBarcode() string {
+	if x != nil {
+		return x.Barcode
+	}
+	return ""
+}
+
+func (x *Product) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Product) GetBrand() string {
+	if x != nil {
+		return x.Brand
+	}
+	return ""
+}
+
+func (x *Product) GetKcal100G() float32 {
+	if x != nil && x.Kcal100G != nil {
+		return *x.Kcal100G
+	}
+	return 0
+}
+
+func (x *Product) GetProtein() float32 {
+	if x != nil && x.Protein != nil {
+		return *x.Protein
+	}
+	return 0
+}
+
+func (x *Product) GetFat() float32 {
+	if x != nil && x.Fat != nil {
+		return *x.Fat
+	}
+	return 0
+}
+
+func (x *Product) GetCarbs() float32 {
+	if x != nil && x.Carbs != nil {
+		return *x.Carbs
+	}
+	return 0
+}
+
+func (x *Product) GetAlternateBarcodes() []string {
+	if x != nil {
+		return x.AlternateBarcodes
+	}
+	return nil
+}
+
+func (x *Product) GetGs1Country() *GS1Country {
+	if x != nil {
+		return x.Gs1Country
+	}
+	return nil
+}
+
+func (x *Product) GetQualityFlags() []string {
+	if x != nil {
+		return x.QualityFlags
+	}
+	return nil
+}
+
+func (x *Product) GetPortion() *Portion {
+	if x != nil {
+		return x.Portion
+	}
+	return nil
+}
+
+// Portion holds nutrients scaled to a requested serving. Nutrients that
+// were not requested or have no value are unset.
+type Portion struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// amount is the requested size in unit ("g" or "oz").
+	Amount        float64  `protobuf:"fixed64,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	Unit          string   `protobuf:"bytes,2,opt,name=unit,proto3" json:"unit,omitempty"`
+	Grams         float64  `protobuf:"fixed64,3,opt,name=grams,proto3" json:"grams,omitempty"`
+	Kcal          *float32 `protobuf:"fixed32,4,opt,name=kcal,proto3,oneof" json:"kcal,omitempty"`
+	Kj            *float32 `protobuf:"fixed32,5,opt,name=kj,proto3,oneof" json:"kj,omitempty"`
+	Protein       *float32 `protobuf:"fixed32,6,opt,name=protein,proto3,oneof" json:"protein,omitempty"`
+	Fat           *float32 `protobuf:"fixed32,7,opt,name=fat,proto3,oneof" json:"fat,omitempty"`
+	Carbs         *float32 `protobuf:"fixed32,8,opt,name=carbs,proto3,oneof" json:"carbs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Portion) Reset() {
+	*x = Portion{}
+	mi := &file_food_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Portion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Portion) ProtoMessage() {}
+
+func (x *Portion) ProtoReflect() protoreflect.Message {
+	mi := &file_food_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Portion.ProtoReflect.Descriptor instead.
+func (*Portion) Descriptor() ([]byte, []int) {
+	return file_food_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Portion) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Portion) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *Portion) GetGrams() float64 {
+	if x != nil {
+		return x.Grams
+	}
+	return 0
+}
+
+func (x *Portion) GetKcal() float32 {
+	if x != nil && x.Kcal != nil {
+		return *x.Kcal
+	}
+	return 0
+}
+
+func (x *Portion) GetKj() float32 {
+	if x != nil && x.Kj != nil {
+		return *x.Kj
+	}
+	return 0
+}
+
+func (x *Portion) GetProtein() float32 {
+	if x != nil && x.Protein != nil {
+		return *x.Protein
+	}
+	return 0
+}
+
+func (x *Portion) GetFat() float32 {
+	if x != nil && x.Fat != nil {
+		return *x.Fat
+	}
+	return 0
+}
+
+func (x *Portion) GetCarbs() float32 {
+	if x != nil && x.Carbs != nil {
+		return *x.Carbs
+	}
+	return 0
+}
+
+// GS1Country describes where a barcode's company prefix was issued.
+type GS1Country struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	PrefixRange   string                 `protobuf:"bytes,3,opt,name=prefix_range,json=prefixRange,proto3" json:"prefix_range,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GS1Country) Reset() {
+	*x = GS1Country{}
+	mi := &file_food_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GS1Country) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GS1Country) ProtoMessage() {}
+
+func (x *GS1Country) ProtoReflect() protoreflect.Message {
+	mi := &file_food_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GS1Country.ProtoReflect.Descriptor instead.
+func (*GS1Country) Descriptor() ([]byte, []int) {
+	return file_food_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GS1Country) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *GS1Country) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GS1Country) GetPrefixRange() string {
+	if x != nil {
+		return x.PrefixRange
+	}
+	return ""
+}
+
+type GetProductRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Barcode       string                 `protobuf:"bytes,1,opt,name=barcode,proto3" json:"barcode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProductRequest) Reset() {
+	*x = GetProductRequest{}
+	mi := &file_food_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProductRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProductRequest) ProtoMessage() {}
+
+func (x *GetProductRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_food_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProductRequest.ProtoReflect.Descriptor instead.
+func (*GetProductRequest) Descriptor() ([]byte, []int) {
+	return file_food_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetProductRequest) GetBarcode() string {
+	if x != nil {
+		return x.Barcode
+	}
+	return ""
+}
+
+type BatchGetProductsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Barcodes      []string               `protobuf:"bytes,1,rep,name=barcodes,proto3" json:"barcodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetProductsRequest) Reset() {
+	*x = BatchGetProductsRequest{}
+	mi := &file_food_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetProductsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetProductsRequest) ProtoMessage() {}
+
+func (x *BatchGetProductsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_food_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetProductsRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetProductsRequest) Descriptor() ([]byte, []int) {
+	return file_food_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchGetProductsRequest) GetBarcodes() []string {
+	if x != nil {
+		return x.Barcodes
+	}
+	return nil
+}
+
+type BatchGetProductsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*ProductResult       `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetProductsResponse) Reset() {
+	*x = BatchGetProductsResponse{}
+	mi := &file_food_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetProductsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetProductsResponse) ProtoMessage() {}
+
+func (x *BatchGetProductsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_food_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetProductsResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetProductsResponse) Descriptor() ([]byte, []int) {
+	return file_food_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchGetProductsResponse) GetResults() []*ProductResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type ProductResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Barcode       string                 `protobuf:"bytes,1,opt,name=barcode,proto3" json:"barcode,omitempty"`
+	Found         bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	Product       *Product               `protobuf:"bytes,3,opt,name=product,proto3" json:"product,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductResult) Reset() {
+	*x = ProductResult{}
+	mi := &file_food_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductResult) ProtoMessage() {}
+
+func (x *ProductResult) ProtoReflect() protoreflect.Message {
+	mi := &file_food_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductResult.ProtoReflect.Descriptor instead.
+func (*ProductResult) Descriptor() ([]byte, []int) {
+	return file_food_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ProductResult) GetBarcode() string {
+	if x != nil {
+		return x.Barcode
+	}
+	return ""
+}
+
+func (x *ProductResult) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *ProductResult) GetProduct() *Product {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+type SearchProductsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Query string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// limit defaults to 20 and is capped at 100.
+	Limit int32  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Lang  string `protobuf:"bytes,3,opt,name=lang,proto3" json:"lang,omitempty"`
+	// gs1_country keeps barcodes issued by this GS1 member organisation (ISO
+	// code); shared ranges match each country, e.g. BE and LU for 540–549.
+	Gs1Country        string `protobuf:"bytes,4,opt,name=gs1_country,json=gs1Country,proto3" json:"gs1_country,omitempty"`
+	Collapse          bool   `protobuf:"varint,5,opt,name=collapse,proto3" json:"collapse,omitempty"`
+	ExcludeLowQuality bool   `protobuf:"varint,6,opt,name=exclude_low_quality,json=excludeLowQuality,proto3" json:"exclude_low_quality,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *SearchProductsRequest) Reset() {
+	*x = SearchProductsRequest{}
+	mi := &file_food_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchProductsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchProductsRequest) ProtoMessage() {}
+
+func (x *SearchProductsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_food_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchProductsRequest.ProtoReflect.Descriptor instead.
+func (*SearchProductsRequest) Descriptor() ([]byte, []int) {
+	return file_food_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SearchProductsRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchProductsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchProductsRequest) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *SearchProductsRequest) GetGs1Country() string {
+	if x != nil {
+		return x.Gs1Country
+	}
+	return ""
+}
+
+func (x *SearchProductsRequest) GetCollapse() bool {
+	if x != nil {
+		return x.Collapse
+	}
+	return false
+}
+
+func (x *SearchProductsRequest) GetExcludeLowQuality() bool {
+	if x != nil {
+		return x.ExcludeLowQuality
+	}
+	return false
+}
+
+type SearchProductsResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Results []*Product             `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	// suggestion is a spelling correction offered for searches with few
+	// results.
+	Suggestion    string `protobuf:"bytes,2,opt,name=suggestion,proto3" json:"suggestion,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchProductsResponse) Reset() {
+	*x = SearchProductsResponse{}
+	mi := &file_food_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchProductsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchProductsResponse) ProtoMessage() {}
+
+func (x *SearchProductsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_food_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchProductsResponse.ProtoReflect.Descriptor instead.
+func (*SearchProductsResponse) Descriptor() ([]byte, []int) {
+	return file_food_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SearchProductsResponse) GetResults() []*Product {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *SearchProductsResponse) GetSuggestion() string {
+	if x != nil {
+		return x.Suggestion
+	}
+	return ""
+}
+
+type GetInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInfoRequest) Reset() {
+	*x = GetInfoRequest{}
+	mi := &file_food_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInfoRequest) ProtoMessage() {}
+
+func (x *GetInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_food_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetInfoRequest) Descriptor() ([]byte, []int) {
+	return file_food_proto_rawDescGZIP(), []int{9}
+}
+
+type GetInfoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SchemaVersion int32                  `protobuf:"varint,1,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	// build_time is RFC 3339; empty without a manifest.
+	BuildTime     string `protobuf:"bytes,2,opt,name=build_time,json=buildTime,proto3" json:"build_time,omitempty"`
+	ProductCount  int64  `protobuf:"varint,3,opt,name=product_count,json=productCount,proto3" json:"product_count,omitempty"`
+	IndexedCount  int64  `protobuf:"varint,4,opt,name=indexed_count,json=indexedCount,proto3" json:"indexed_count,omitempty"`
+	SearchBackend string `protobuf:"bytes,5,opt,name=search_backend,json=searchBackend,proto3" json:"search_backend,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInfoResponse) Reset() {
+	*x = GetInfoResponse{}
+	mi := &file_food_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInfoResponse) ProtoMessage() {}
+
+func (x *GetInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_food_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInfoResponse.ProtoReflect.Descriptor instead.
+func (*GetInfoResponse) Descriptor() ([]byte, []int) {
+	return file_food_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetInfoResponse) GetSchemaVersion() int32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *GetInfoResponse) GetBuildTime() string {
+	if x != nil {
+		return x.BuildTime
+	}
+	return ""
+}
+
+func (x *GetInfoResponse) GetProductCount() int64 {
+	if x != nil {
+		return x.ProductCount
+	}
+	return 0
+}
+
+func (x *GetInfoResponse) GetIndexedCount() int64 {
+	if x != nil {
+		return x.IndexedCount
+	}
+	return 0
+}
+
+func (x *GetInfoResponse) GetSearchBackend() string {
+	if x != nil {
+		return x.SearchBackend
+	}
+	return ""
+}
+
+var File_food_proto protoreflect.FileDescriptor
+
+const file_food_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"food.proto\x12\rfastfooddb.v1\"\xac\x03\n" +
+	"\aProduct\x12\x18\n" +
+	"\abarcode\x18\x01 \x01(\tR\abarcode\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05brand\x18\x03 \x01(\tR\x05brand\x12\x1f\n" +
+	"\bkcal100g\x18\x04 \x01(\x02H\x00R\bkcal100g\x88\x01\x01\x12\x1d\n" +
+	"\aprotein\x18\x05 \x01(\x02H\x01R\aprotein\x88\x01\x01\x12\x15\n" +
+	"\x03fat\x18\x06 \x01(\x02H\x02R\x03fat\x88\x01\x01\x12\x19\n" +
+	"\x05carbs\x18\a \x01(\x02H\x03R\x05carbs\x88\x01\x01\x12-\n" +
+	"\x12alternate_barcodes\x18\b \x03(\tR\x11alternateBarcodes\x12:\n" +
+	"\vgs1_country\x18\t \x01(\v2\x19.fastfooddb.v1.GS1CountryR\n" +
+	"gs1Country\x12#\n" +
+	"\rquality_flags\x18\n" +
+	" \x03(\tR\fqualityFlags\x120\n" +
+	"\aportion\x18\v \x01(\v2\x16.fastfooddb.v1.PortionR\aportionB\v\n" +
+	"\t_kcal100gB\n" +
+	"\n" +
+	"\b_proteinB\x06\n" +
+	"\x04_fatB\b\n" +
+	"\x06_carbs\"\xf8\x01\n" +
+	"\aPortion\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x01R\x06amount\x12\x12\n" +
+	"\x04unit\x18\x02 \x01(\tR\x04unit\x12\x14\n" +
+	"\x05grams\x18\x03 \x01(\x01R\x05grams\x12\x17\n" +
+	"\x04kcal\x18\x04 \x01(\x02H\x00R\x04kcal\x88\x01\x01\x12\x13\n" +
+	"\x02kj\x18\x05 \x01(\x02H\x01R\x02kj\x88\x01\x01\x12\x1d\n" +
+	"\aprotein\x18\x06 \x01(\x02H\x02R\aprotein\x88\x01\x01\x12\x15\n" +
+	"\x03fat\x18\a \x01(\x02H\x03R\x03fat\x88\x01\x01\x12\x19\n" +
+	"\x05carbs\x18\b \x01(\x02H\x04R\x05carbs\x88\x01\x01B\a\n" +
+	"\x05_kcalB\x05\n" +
+	"\x03_kjB\n" +
+	"\n" +
+	"\b_proteinB\x06\n" +
+	"\x04_fatB\b\n" +
+	"\x06_carbs\"W\n" +
+	"\n" +
+	"GS1Country\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12!\n" +
+	"\fprefix_range\x18\x03 \x01(\tR\vprefixRange\"-\n" +
+	"\x11GetProductRequest\x12\x18\n" +
+	"\abarcode\x18\x01 \x01(\tR\abarcode\"5\n" +
+	"\x17BatchGetProductsRequest\x12\x1a\n" +
+	"\bbarcodes\x18\x01 \x03(\tR\bbarcodes\"R\n" +
+	"\x18BatchGetProductsResponse\x126\n" +
+	"\aresults\x18\x01 \x03(\v2\x1c.fastfooddb.v1.ProductResultR\aresults\"q\n" +
+	"\rProductResult\x12\x18\n" +
+	"\abarcode\x18\x01 \x01(\tR\abarcode\x12\x14\n" +
+	"\x05found\x18\x02 \x01(\bR\x05found\x120\n" +
+	"\aproduct\x18\x03 \x01(\v2\x16.fastfooddb.v1.ProductR\aproduct\"\xc4\x01\n" +
+	"\x15SearchProductsRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x12\n" +
+	"\x04lang\x18\x03 \x01(\tR\x04lang\x12\x1f\n" +
+	"\vgs1_country\x18\x04 \x01(\tR\n" +
+	"gs1Country\x12\x1a\n" +
+	"\bcollapse\x18\x05 \x01(\bR\bcollapse\x12.\n" +
+	"\x13exclude_low_quality\x18\x06 \x01(\bR\x11excludeLowQuality\"j\n" +
+	"\x16SearchProductsResponse\x120\n" +
+	"\aresults\x18\x01 \x03(\v2\x16.fastfooddb.v1.ProductR\aresults\x12\x1e\n" +
+	"\n" +
+	"suggestion\x18\x02 \x01(\tR\n" +
+	"suggestion\"\x10\n" +
+	"\x0eGetInfoRequest\"\xc8\x01\n" +
+	"\x0fGetInfoResponse\x12%\n" +
+	"\x0eschema_version\x18\x01 \x01(\x05R\rschemaVersion\x12\x1d\n" +
+	"\n" +
+	"build_time\x18\x02 \x01(\tR\tbuildTime\x12#\n" +
+	"\rproduct_count\x18\x03 \x01(\x03R\fproductCount\x12#\n" +
+	"\rindexed_count\x18\x04 \x01(\x03R\findexedCount\x12%\n" +
+	"\x0esearch_backend\x18\x05 \x01(\tR\rsearchBackend2\xe3\x02\n" +
+	"\vFoodService\x12F\n" +
+	"\n" +
+	"GetProduct\x12 .fastfooddb.v1.GetProductRequest\x1a\x16.fastfooddb.v1.Product\x12c\n" +
+	"\x10BatchGetProducts\x12&.fastfooddb.v1.BatchGetProductsRequest\x1a'.fastfooddb.v1.BatchGetProductsResponse\x12]\n" +
+	"\x0eSearchProducts\x12$.fastfooddb.v1.SearchProductsRequest\x1a%.fastfooddb.v1.SearchProductsResponse\x12H\n" +
+	"\aGetInfo\x12\x1d.fastfooddb.v1.GetInfoRequest\x1a\x1e.fastfooddb.v1.GetInfoResponseB0Z.github.com/korjavin/fastfooddb/internal/foodpbb\x06proto3"
+
+var (
+	file_food_proto_rawDescOnce sync.Once
+	file_food_proto_rawDescData []byte
+)
+
+func file_food_proto_rawDescGZIP() []byte {
+	file_food_proto_rawDescOnce.Do(func() {
+		file_food_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_food_proto_rawDesc), len(file_food_proto_rawDesc)))
+	})
+	return file_food_proto_rawDescData
+}
+
+var file_food_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_food_proto_goTypes = []any{
+	(*Product)(nil),                  // 0: fastfooddb.v1.Product
+	(*Portion)(nil),                  // 1: fastfooddb.v1.Portion
+	(*GS1Country)(nil),               // 2: fastfooddb.v1.GS1Country
+	(*GetProductRequest)(nil),        // 3: fastfooddb.v1.GetProductRequest
+	(*BatchGetProductsRequest)(nil),  // 4: fastfooddb.v1.BatchGetProductsRequest
+	(*BatchGetProductsResponse)(nil), // 5: fastfooddb.v1.BatchGetProductsResponse
+	(*ProductResult)(nil),            // 6: fastfooddb.v1.ProductResult
+	(*SearchProductsRequest)(nil),    // 7: fastfooddb.v1.SearchProductsRequest
+	(*SearchProductsResponse)(nil),   // 8: fastfooddb.v1.SearchProductsResponse
+	(*GetInfoRequest)(nil),           // 9: fastfooddb.v1.GetInfoRequest
+	(*GetInfoResponse)(nil),          // 10: fastfooddb.v1.GetInfoResponse
+}
+var file_food_proto_depIdxs = []int32{
+	2,  // 0: fastfooddb.v1.Product.gs1_country:type_name -> fastfooddb.v1.GS1Country
+	1,  // 1: fastfooddb.v1.Product.portion:type_name -> fastfooddb.v1.Portion
+	6,  // 2: fastfooddb.v1.BatchGetProductsResponse.results:type_name -> fastfooddb.v1.ProductResult
+	0,  // 3: fastfooddb.v1.ProductResult.product:type_name -> fastfooddb.v1.Product
+	0,  // 4: fastfooddb.v1.SearchProductsResponse.results:type_name -> fastfooddb.v1.Product
+	3,  // 5: fastfooddb.v1.FoodService.GetProduct:input_type -> fastfooddb.v1.GetProductRequest
+	4,  // 6: fastfooddb.v1.FoodService.BatchGetProducts:input_type -> fastfooddb.v1.BatchGetProductsRequest
+	7,  // 7: fastfooddb.v1.FoodService.SearchProducts:input_type -> fastfooddb.v1.SearchProductsRequest
+	9,  // 8: fastfooddb.v1.FoodService.GetInfo:input_type -> fastfooddb.v1.GetInfoRequest
+	0,  // 9: fastfooddb.v1.FoodService.GetProduct:output_type -> fastfooddb.v1.Product
+	5,  // 10: fastfooddb.v1.FoodService.BatchGetProducts:output_type -> fastfooddb.v1.BatchGetProductsResponse
+	8,  // 11: fastfooddb.v1.FoodService.SearchProducts:output_type -> fastfooddb.v1.SearchProductsResponse
+	10, // 12: fastfooddb.v1.FoodService.GetInfo:output_type -> fastfooddb.v1.GetInfoResponse
+	9,  // [9:13] is the sub-list for method output_type
+	5,  // [5:9] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_food_proto_init() }
+func file_food_proto_init() {
+	if File_food_proto != nil {
+		return
+	}
+	file_food_proto_msgTypes[0].OneofWrappers = []any{}
+	file_food_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_food_proto_rawDesc), len(file_food_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_food_proto_goTypes,
+		DependencyIndexes: file_food_proto_depIdxs,
+		MessageInfos:      file_food_proto_msgTypes,
+	}.Build()
+	File_food_proto = out.File
+	file_food_proto_goTypes = nil
+	file_food_proto_depIdxs = nil
+}
@@ -0,0 +1,5 @@
+// Package foodpb holds the protobuf messages and gRPC service generated from
+// food.proto.
+package foodpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative food.proto
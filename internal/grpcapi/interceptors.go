@@ -0,0 +1,88 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/korjavin/fastfooddb/internal/auth"
+	"github.com/korjavin/fastfooddb/internal/foodpb"
+	"github.com/korjavin/fastfooddb/internal/middleware"
+)
+
+// apiKeyMetadata is the metadata key carrying the API key, the gRPC
+// counterpart of the X-API-Key header.
+const apiKeyMetadata = "x-api-key"
+
+// publicMethods need no API key, like /health over HTTP.
+var publicMethods = map[string]bool{
+	foodpb.FoodService_GetInfo_FullMethodName: true,
+}
+
+// Logging returns an interceptor that logs each call with structured fields.
+func Logging(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logger.Info("grpc request",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"duration", time.Since(start),
+			"ip", clientIP(ctx),
+		)
+		return resp, err
+	}
+}
+
+// RateLimit returns an interceptor drawing on the same per-IP limiter as the
+// HTTP middleware.
+func RateLimit(l *middleware.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !l.Allow(clientIP(ctx)) {
+			return nil, status.Error(codes.ResourceExhausted, "too many requests")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// APIKey returns an interceptor that validates the x-api-key metadata entry
+// on every method but GetInfo. If no keys are configured, all calls are
+// allowed through.
+func APIKey(validKeys []string) grpc.UnaryServerInterceptor {
+	keySet := auth.NewKeySet(validKeys)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !publicMethods[info.FullMethod] && !keySet.Allows(firstMetadata(ctx, apiKeyMetadata)) {
+			return nil, status.Error(codes.Unauthenticated, "unauthorized")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	if vals := metadata.ValueFromIncomingContext(ctx, key); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// clientIP extracts the client IP from proxy metadata or the peer address,
+// like the HTTP middleware's realIP.
+func clientIP(ctx context.Context) string {
+	if xff := firstMetadata(ctx, "x-forwarded-for"); xff != "" {
+		return strings.Split(xff, ",")[0]
+	}
+	if xri := firstMetadata(ctx, "x-real-ip"); xri != "" {
+		return xri
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr.String()
+	}
+	return ""
+}
@@ -0,0 +1,195 @@
+// Package grpcapi serves the foodpb.FoodService gRPC API from the same store,
+// API keys, rate limiter and latency histograms as the HTTP API.
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/korjavin/fastfooddb/internal/barcode"
+	"github.com/korjavin/fastfooddb/internal/foodpb"
+	"github.com/korjavin/fastfooddb/internal/metrics"
+	"github.com/korjavin/fastfooddb/internal/store"
+)
+
+const (
+	// didYouMeanThreshold is the result count below which a search response
+	// carries a spelling suggestion, as over HTTP.
+	didYouMeanThreshold = 3
+
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxBatchBarcodes   = 100
+)
+
+// Server implements foodpb.FoodServiceServer.
+type Server struct {
+	foodpb.UnimplementedFoodServiceServer
+
+	Products      store.ProductLookup
+	Searcher      store.ProductSearcher
+	Suggester     store.NameSuggester
+	Manifest      *store.Manifest
+	SearchBackend string
+	BarcodeHist   *metrics.Histogram // nil-safe
+	SearchHist    *metrics.Histogram // nil-safe
+}
+
+// Register registers the FoodService on gs. Lookups and suggestions come
+// from s and searches go to searcher, as in api.RegisterRoutes; latencies
+// are recorded in the histograms the HTTP handlers use.
+func Register(gs *grpc.Server, s *store.Store, searcher store.ProductSearcher, backend string, m *store.Manifest, reg *metrics.Registry) {
+	srv := &Server{Products: s, Searcher: searcher, Suggester: s, Manifest: m, SearchBackend: backend}
+	if reg != nil {
+		srv.BarcodeHist = reg.Register("barcode_get", metrics.BucketsBarcode)
+		srv.SearchHist = reg.Register("search", metrics.BucketsSearch)
+	}
+	foodpb.RegisterFoodServiceServer(gs, srv)
+}
+
+// GetProduct looks up one product by barcode.
+func (s *Server) GetProduct(ctx context.Context, req *foodpb.GetProductRequest) (*foodpb.Product, error) {
+	if req.GetBarcode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing barcode")
+	}
+	p, found, err := s.get(req.GetBarcode())
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return toProduct(p), nil
+}
+
+// BatchGetProducts looks up several barcodes, keeping request order.
+func (s *Server) BatchGetProducts(ctx context.Context, req *foodpb.BatchGetProductsRequest) (*foodpb.BatchGetProductsResponse, error) {
+	barcodes := req.GetBarcodes()
+	if len(barcodes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "barcodes must not be empty")
+	}
+	if len(barcodes) > maxBatchBarcodes {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d barcodes allowed", maxBatchBarcodes)
+	}
+
+	resp := &foodpb.BatchGetProductsResponse{Results: make([]*foodpb.ProductResult, len(barcodes))}
+	for i, bc := range barcodes {
+		res := &foodpb.ProductResult{Barcode: bc}
+		if bc != "" {
+			p, found, err := s.get(bc)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				res.Found = true
+				res.Product = toProduct(p)
+			}
+		}
+		resp.Results[i] = res
+	}
+	return resp, nil
+}
+
+// get reads a product and records the lookup latency.
+func (s *Server) get(bc string) (store.Product, bool, error) {
+	t0 := time.Now()
+	p, found, err := s.Products.Get(bc)
+	if s.BarcodeHist != nil {
+		s.BarcodeHist.Observe(time.Since(t0))
+	}
+	if err != nil {
+		slog.Error("grpc barcode lookup failed", "barcode", bc, "error", err)
+		return store.Product{}, false, status.Error(codes.Internal, "internal server error")
+	}
+	return p, found, nil
+}
+
+// SearchProducts searches products by name.
+func (s *Server) SearchProducts(ctx context.Context, req *foodpb.SearchProductsRequest) (*foodpb.SearchProductsResponse, error) {
+	q := req.GetQuery()
+	if q == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing query")
+	}
+	limit := defaultSearchLimit
+	if n := int(req.GetLimit()); n > 0 {
+		limit = min(n, maxSearchLimit)
+	}
+	opts := store.SearchOptions{
+		Limit:             limit,
+		Lang:              req.GetLang(),
+		GS1Country:        req.GetGs1Country(),
+		Collapse:          req.GetCollapse(),
+		ExcludeLowQuality: req.GetExcludeLowQuality(),
+	}
+
+	t0 := time.Now()
+	products, err := s.Searcher.SearchWith(q, opts)
+	if s.SearchHist != nil {
+		s.SearchHist.Observe(time.Since(t0))
+	}
+	if err != nil {
+		slog.Error("grpc search failed", "query", q, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	resp := &foodpb.SearchProductsResponse{Results: make([]*foodpb.Product, len(products))}
+	for i, p := range products {
+		resp.Results[i] = toProduct(p)
+	}
+	if len(products) < didYouMeanThreshold {
+		if suggestion, err := s.Suggester.DidYouMean(q); err != nil {
+			slog.Warn("did-you-mean failed", "query", q, "error", err)
+		} else {
+			resp.Suggestion = suggestion
+		}
+	}
+	return resp, nil
+}
+
+// GetInfo reports the manifest of the loaded dataset and the search backend.
+func (s *Server) GetInfo(ctx context.Context, req *foodpb.GetInfoRequest) (*foodpb.GetInfoResponse, error) {
+	resp := &foodpb.GetInfoResponse{SearchBackend: s.SearchBackend}
+	if resp.SearchBackend == "" {
+		resp.SearchBackend = store.SearchBackendBleve
+	}
+	if m := s.Manifest; m != nil {
+		resp.SchemaVersion = int32(m.SchemaVersion)
+		resp.BuildTime = m.BuildTime.Format(time.RFC3339)
+		resp.ProductCount = m.ProductCount
+		resp.IndexedCount = m.IndexedCount
+	}
+	return resp, nil
+}
+
+// toProduct converts p; NaN nutrients are left unset like JSON nulls.
+func toProduct(p store.Product) *foodpb.Product {
+	out := &foodpb.Product{
+		Barcode:           p.Barcode,
+		Name:              p.Name,
+		Brand:             p.Brand,
+		Kcal100G:          nanToNil(p.Kcal100g),
+		Protein:           nanToNil(p.Protein),
+		Fat:               nanToNil(p.Fat),
+		Carbs:             nanToNil(p.Carbs),
+		AlternateBarcodes: p.Alternates,
+		QualityFlags:      p.Quality.Names(),
+	}
+	if c, ok := barcode.GS1Country(p.Barcode); ok {
+		out.Gs1Country = &foodpb.GS1Country{Code: c.Code, Name: c.Country, PrefixRange: c.Range()}
+	}
+	return out
+}
+
+func nanToNil(f float32) *float32 {
+	if math.IsNaN(float64(f)) {
+		return nil
+	}
+	return proto.Float32(f)
+}
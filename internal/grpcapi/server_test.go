@@ -0,0 +1,192 @@
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/korjavin/fastfooddb/internal/foodpb"
+	"github.com/korjavin/fastfooddb/internal/metrics"
+	"github.com/korjavin/fastfooddb/internal/middleware"
+	"github.com/korjavin/fastfooddb/internal/store"
+)
+
+// fakeBackend serves a fixed product set.
+type fakeBackend map[string]store.Product
+
+func (f fakeBackend) Get(bc string) (store.Product, bool, error) {
+	p, ok := f[bc]
+	return p, ok, nil
+}
+
+func (f fakeBackend) ScanPrefix(prefix, cursor string, limit int) ([]store.Product, string, error) {
+	return nil, "", nil
+}
+
+func (f fakeBackend) SearchWith(q string, opts store.SearchOptions) ([]store.Product, error) {
+	var out []store.Product
+	for _, p := range f {
+		if strings.Contains(strings.ToLower(p.Name), strings.ToLower(q)) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f fakeBackend) Suggest(q string, k int) ([]store.Suggestion, error) { return nil, nil }
+func (f fakeBackend) DidYouMean(q string) (string, error)                 { return "nutella", nil }
+
+func newTestClient(t *testing.T, keys []string, limiter *middleware.Limiter) (foodpb.FoodServiceClient, *Server) {
+	t.Helper()
+	backend := fakeBackend{
+		"3017620422003": {Barcode: "3017620422003", Name: "Nutella", Kcal100g: 539, Protein: 6.3, Fat: 30.9, Carbs: 57.5},
+		"5000112637922": {Barcode: "5000112637922", Name: "Coca-Cola", Kcal100g: 42, Protein: float32(math.NaN()), Fat: 0, Carbs: 10.6},
+	}
+	reg := metrics.NewRegistry()
+	srv := &Server{
+		Products:    backend,
+		Searcher:    backend,
+		Suggester:   backend,
+		Manifest:    &store.Manifest{SchemaVersion: 3, ProductCount: 2, BuildTime: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+		BarcodeHist: reg.Register("barcode_get", metrics.BucketsBarcode),
+		SearchHist:  reg.Register("search", metrics.BucketsSearch),
+	}
+	gs := grpc.NewServer(grpc.ChainUnaryInterceptor(RateLimit(limiter), APIKey(keys)))
+	foodpb.RegisterFoodServiceServer(gs, srv)
+
+	lis := bufconn.Listen(1 << 20)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return foodpb.NewFoodServiceClient(conn), srv
+}
+
+func TestGetProduct(t *testing.T) {
+	c, srv := newTestClient(t, nil, middleware.NewLimiter(100, 20))
+	ctx := context.Background()
+
+	p, err := c.GetProduct(ctx, &foodpb.GetProductRequest{Barcode: "5000112637922"})
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if p.GetName() != "Coca-Cola" || p.GetKcal100G() != 42 {
+		t.Errorf("got %v", p)
+	}
+	if p.Protein != nil {
+		t.Errorf("NaN protein = %v, want unset", *p.Protein)
+	}
+	if p.Fat == nil || *p.Fat != 0 {
+		t.Errorf("zero fat = %v, want set to 0", p.Fat)
+	}
+	if p.GetGs1Country().GetCode() == "" {
+		t.Errorf("missing GS1 country")
+	}
+	if srv.BarcodeHist.Snapshot().Total != 1 {
+		t.Errorf("barcode histogram total = %d, want 1", srv.BarcodeHist.Snapshot().Total)
+	}
+
+	_, err = c.GetProduct(ctx, &foodpb.GetProductRequest{Barcode: "0000000000000"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unknown barcode: got %v, want NotFound", err)
+	}
+	_, err = c.GetProduct(ctx, &foodpb.GetProductRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("empty barcode: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestBatchGetProducts(t *testing.T) {
+	c, _ := newTestClient(t, nil, middleware.NewLimiter(100, 20))
+	resp, err := c.BatchGetProducts(context.Background(), &foodpb.BatchGetProductsRequest{
+		Barcodes: []string{"3017620422003", "0000000000000", "5000112637922"},
+	})
+	if err != nil {
+		t.Fatalf("BatchGetProducts: %v", err)
+	}
+	var got []string
+	for _, r := range resp.GetResults() {
+		got = append(got, r.GetBarcode()+":"+r.GetProduct().GetName())
+		if r.GetFound() != (r.GetProduct() != nil) {
+			t.Errorf("%s: found = %v with product %v", r.GetBarcode(), r.GetFound(), r.GetProduct())
+		}
+	}
+	want := "3017620422003:Nutella 0000000000000: 5000112637922:Coca-Cola"
+	if strings.Join(got, " ") != want {
+		t.Errorf("results = %q, want %q", strings.Join(got, " "), want)
+	}
+
+	_, err = c.BatchGetProducts(context.Background(), &foodpb.BatchGetProductsRequest{Barcodes: make([]string, maxBatchBarcodes+1)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("oversized batch: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestSearchProducts(t *testing.T) {
+	c, _ := newTestClient(t, nil, middleware.NewLimiter(100, 20))
+	resp, err := c.SearchProducts(context.Background(), &foodpb.SearchProductsRequest{Query: "nutel"})
+	if err != nil {
+		t.Fatalf("SearchProducts: %v", err)
+	}
+	if len(resp.GetResults()) != 1 || resp.GetResults()[0].GetBarcode() != "3017620422003" {
+		t.Errorf("results = %v", resp.GetResults())
+	}
+	if resp.GetSuggestion() != "nutella" {
+		t.Errorf("suggestion = %q, want nutella", resp.GetSuggestion())
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	c, _ := newTestClient(t, []string{"secret"}, middleware.NewLimiter(100, 20))
+	ctx := context.Background()
+
+	_, err := c.GetProduct(ctx, &foodpb.GetProductRequest{Barcode: "3017620422003"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("no key: got %v, want Unauthenticated", err)
+	}
+	bad := metadata.AppendToOutgoingContext(ctx, "x-api-key", "wrong")
+	if _, err := c.GetProduct(bad, &foodpb.GetProductRequest{Barcode: "3017620422003"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("wrong key: got %v, want Unauthenticated", err)
+	}
+	good := metadata.AppendToOutgoingContext(ctx, "x-api-key", "secret")
+	if _, err := c.GetProduct(good, &foodpb.GetProductRequest{Barcode: "3017620422003"}); err != nil {
+		t.Errorf("valid key: %v", err)
+	}
+
+	info, err := c.GetInfo(ctx, &foodpb.GetInfoRequest{})
+	if err != nil {
+		t.Fatalf("GetInfo without key: %v", err)
+	}
+	if info.GetSchemaVersion() != 3 || info.GetBuildTime() != "2026-01-02T03:04:05Z" || info.GetSearchBackend() != store.SearchBackendBleve {
+		t.Errorf("info = %v", info)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	c, _ := newTestClient(t, nil, middleware.NewLimiter(0.001, 2))
+	ctx := context.Background()
+	for i := range 2 {
+		if _, err := c.GetInfo(ctx, &foodpb.GetInfoRequest{}); err != nil {
+			t.Fatalf("call %d within burst: %v", i, err)
+		}
+	}
+	if _, err := c.GetInfo(ctx, &foodpb.GetInfoRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("call over burst: got %v, want ResourceExhausted", err)
+	}
+}
@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/korjavin/fastfooddb/internal/store"
+)
+
+// DuplicatePolicy decides which record wins when the dump contains the same
+// barcode more than once.
+type DuplicatePolicy string
+
+const (
+	// DuplicateLatest keeps the record with the highest last_modified_t,
+	// falling back to the most complete nutriments on a tie.
+	DuplicateLatest DuplicatePolicy = "latest"
+	// DuplicateComplete keeps the record with the most nutriments present,
+	// falling back to the highest last_modified_t on a tie.
+	DuplicateComplete DuplicatePolicy = "complete"
+)
+
+// ParseDuplicatePolicy validates a policy name from the command line.
+func ParseDuplicatePolicy(s string) (DuplicatePolicy, error) {
+	switch p := DuplicatePolicy(s); p {
+	case DuplicateLatest, DuplicateComplete:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown duplicate policy %q (want %q or %q)", s, DuplicateLatest, DuplicateComplete)
+}
+
+// seenProduct is what the importer remembers about a stored barcode so a
+// later duplicate can be resolved and its counters undone.
+type seenProduct struct {
+	line     int64 // dump line of the stored record
+	modified int64
+	complete uint8 // nutriments present in the dump, 0–4
+	quality  store.QualityFlags
+	name     string
+}
+
+// nutrimentCount returns how many of kcal, protein, fat and carbs are present.
+func nutrimentCount(vs ...float32) uint8 {
+	var n uint8
+	for _, v := range vs {
+		if !math.IsNaN(float64(v)) {
+			n++
+		}
+	}
+	return n
+}
+
+// prefer reports whether cand should replace cur. On a full tie the record
+// already stored is kept, so the first occurrence in the dump wins.
+func (pol DuplicatePolicy) prefer(cand, cur seenProduct) bool {
+	if pol == DuplicateComplete {
+		if cand.complete != cur.complete {
+			return cand.complete > cur.complete
+		}
+		return cand.modified > cur.modified
+	}
+	if cand.modified != cur.modified {
+		return cand.modified > cur.modified
+	}
+	return cand.complete > cur.complete
+}
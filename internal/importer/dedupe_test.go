@@ -0,0 +1,50 @@
+package importer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDuplicatePolicyPrefer(t *testing.T) {
+	old := seenProduct{modified: 100, complete: 4}
+	newer := seenProduct{modified: 200, complete: 2}
+
+	tests := []struct {
+		pol        DuplicatePolicy
+		cand, cur  seenProduct
+		wantPrefer bool
+	}{
+		{DuplicateLatest, newer, old, true},
+		{DuplicateLatest, old, newer, false},
+		{DuplicateComplete, newer, old, false},
+		{DuplicateComplete, old, newer, true},
+		// Ties fall through to the secondary key, then keep the stored record.
+		{DuplicateLatest, seenProduct{modified: 100, complete: 3}, seenProduct{modified: 100, complete: 2}, true},
+		{DuplicateComplete, seenProduct{modified: 300, complete: 2}, seenProduct{modified: 100, complete: 2}, true},
+		{DuplicateLatest, old, old, false},
+		{DuplicateComplete, old, old, false},
+	}
+	for _, tc := range tests {
+		if got := tc.pol.prefer(tc.cand, tc.cur); got != tc.wantPrefer {
+			t.Errorf("%s.prefer(%+v, %+v) = %v; want %v", tc.pol, tc.cand, tc.cur, got, tc.wantPrefer)
+		}
+	}
+}
+
+func TestParseDuplicatePolicy(t *testing.T) {
+	for _, s := range []string{"latest", "complete"} {
+		if p, err := ParseDuplicatePolicy(s); err != nil || string(p) != s {
+			t.Errorf("ParseDuplicatePolicy(%q) = %q, %v", s, p, err)
+		}
+	}
+	if _, err := ParseDuplicatePolicy("first"); err == nil {
+		t.Error("ParseDuplicatePolicy(\"first\") succeeded; want error")
+	}
+}
+
+func TestNutrimentCount(t *testing.T) {
+	nan := float32(math.NaN())
+	if got := nutrimentCount(1, nan, 0, nan); got != 2 {
+		t.Errorf("nutrimentCount = %d; want 2", got)
+	}
+}
@@ -0,0 +1,399 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Filter is a compiled import filter expression evaluated against each parsed
+// OFF record. The grammar is:
+//
+//	expr    = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | "(" expr ")" | "has" "(" field ")" | field op literal
+//	op      = "==" | "!=" | "<" | "<=" | ">" | ">=" | "contains"
+//	literal = "quoted string" | number
+//
+// For example:
+//
+//	countries_tags contains "en:germany" && has(protein) && has(kcal)
+//
+// Tag lists only support contains (exact element match); strings support
+// ==, != and contains (substring); numbers support the ordered comparisons.
+// Missing nutriments never satisfy a comparison. See filterFields for the
+// available field names.
+type Filter struct {
+	src  string
+	root filterNode
+}
+
+// String returns the expression the filter was parsed from, or "" for a nil
+// filter.
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.src
+}
+
+// Match reports whether p passes the filter.
+func (f *Filter) Match(p *OFFProduct) bool {
+	return f.root.eval(p)
+}
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindList
+	kindNumber
+)
+
+func (k fieldKind) String() string {
+	return [...]string{"string", "tag list", "number"}[k]
+}
+
+// filterField exposes one OFFProduct attribute to filter expressions.
+// Exactly one accessor is set, matching kind. Missing numbers are NaN.
+type filterField struct {
+	kind fieldKind
+	str  func(*OFFProduct) string
+	list func(*OFFProduct) []string
+	num  func(*OFFProduct) float64
+}
+
+// optional maps an unset count or timestamp (0) to NaN so has() and
+// comparisons treat it as missing.
+func optional(v float64) float64 {
+	if v == 0 {
+		return math.NaN()
+	}
+	return v
+}
+
+var filterFields = map[string]filterField{
+	"code":            {kind: kindString, str: func(p *OFFProduct) string { return p.Code }},
+	"product_name":    {kind: kindString, str: (*OFFProduct).Name},
+	"brands":          {kind: kindString, str: func(p *OFFProduct) string { return p.Brands }},
+	"lang":            {kind: kindString, str: func(p *OFFProduct) string { return p.Lang }},
+	"countries_tags":  {kind: kindList, list: func(p *OFFProduct) []string { return p.CountriesTags }},
+	"categories_tags": {kind: kindList, list: func(p *OFFProduct) []string { return p.CategoriesTags }},
+	"kcal":            {kind: kindNumber, num: func(p *OFFProduct) float64 { return float64(p.Kcal100g()) }},
+	"protein":         {kind: kindNumber, num: func(p *OFFProduct) float64 { return float64(p.Protein100g()) }},
+	"fat":             {kind: kindNumber, num: func(p *OFFProduct) float64 { return float64(p.Fat100g()) }},
+	"carbs":           {kind: kindNumber, num: func(p *OFFProduct) float64 { return float64(p.Carbs100g()) }},
+	"unique_scans_n":  {kind: kindNumber, num: func(p *OFFProduct) float64 { return optional(p.UniqueScansN) }},
+	"last_modified_t": {kind: kindNumber, num: func(p *OFFProduct) float64 { return optional(float64(p.LastModifiedT)) }},
+}
+
+// ParseFilter compiles a filter expression, checking field names and operator
+// types up front so a typo fails before the import starts.
+func ParseFilter(src string) (*Filter, error) {
+	toks, err := lexFilter(src)
+	if err != nil {
+		return nil, fmt.Errorf("filter: %w", err)
+	}
+	ps := &filterParser{toks: toks}
+	root, err := ps.parseOr()
+	if err == nil && ps.peek().kind != tokEOF {
+		err = fmt.Errorf("unexpected %s at offset %d", ps.peek(), ps.peek().pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("filter: %w", err)
+	}
+	return &Filter{src: src, root: root}, nil
+}
+
+// ---- evaluation ----
+
+type filterNode interface {
+	eval(p *OFFProduct) bool
+}
+
+type andNode struct{ l, r filterNode }
+
+func (n andNode) eval(p *OFFProduct) bool { return n.l.eval(p) && n.r.eval(p) }
+
+type orNode struct{ l, r filterNode }
+
+func (n orNode) eval(p *OFFProduct) bool { return n.l.eval(p) || n.r.eval(p) }
+
+type notNode struct{ x filterNode }
+
+func (n notNode) eval(p *OFFProduct) bool { return !n.x.eval(p) }
+
+type hasNode struct{ f filterField }
+
+func (n hasNode) eval(p *OFFProduct) bool {
+	switch n.f.kind {
+	case kindString:
+		return n.f.str(p) != ""
+	case kindList:
+		return len(n.f.list(p)) > 0
+	default:
+		return !math.IsNaN(n.f.num(p))
+	}
+}
+
+type cmpNode struct {
+	f   filterField
+	op  string
+	str string
+	num float64
+}
+
+func (n cmpNode) eval(p *OFFProduct) bool {
+	switch n.f.kind {
+	case kindList:
+		return slices.Contains(n.f.list(p), n.str)
+	case kindString:
+		v := n.f.str(p)
+		switch n.op {
+		case "==":
+			return v == n.str
+		case "!=":
+			return v != n.str
+		default: // contains
+			return strings.Contains(v, n.str)
+		}
+	}
+	v := n.f.num(p)
+	if math.IsNaN(v) {
+		return false
+	}
+	switch n.op {
+	case "==":
+		return v == n.num
+	case "!=":
+		return v != n.num
+	case "<":
+		return v < n.num
+	case "<=":
+		return v <= n.num
+	case ">":
+		return v > n.num
+	default: // >=
+		return v >= n.num
+	}
+}
+
+// ---- parsing ----
+
+type filterParser struct {
+	toks []filterToken
+	pos  int
+}
+
+func (ps *filterParser) peek() filterToken { return ps.toks[ps.pos] }
+
+func (ps *filterParser) next() filterToken {
+	t := ps.toks[ps.pos]
+	if t.kind != tokEOF {
+		ps.pos++
+	}
+	return t
+}
+
+func (ps *filterParser) expect(kind tokKind, text string) error {
+	t := ps.next()
+	if t.kind != kind || (text != "" && t.text != text) {
+		want := text
+		if want == "" {
+			want = kind.String()
+		}
+		return fmt.Errorf("expected %s at offset %d, got %s", want, t.pos, t)
+	}
+	return nil
+}
+
+func (ps *filterParser) parseOr() (filterNode, error) {
+	l, err := ps.parseAnd()
+	for err == nil && ps.peek().is(tokOp, "||") {
+		ps.next()
+		var r filterNode
+		if r, err = ps.parseAnd(); err == nil {
+			l = orNode{l, r}
+		}
+	}
+	return l, err
+}
+
+func (ps *filterParser) parseAnd() (filterNode, error) {
+	l, err := ps.parseUnary()
+	for err == nil && ps.peek().is(tokOp, "&&") {
+		ps.next()
+		var r filterNode
+		if r, err = ps.parseUnary(); err == nil {
+			l = andNode{l, r}
+		}
+	}
+	return l, err
+}
+
+func (ps *filterParser) parseUnary() (filterNode, error) {
+	t := ps.next()
+	switch {
+	case t.is(tokOp, "!"):
+		x, err := ps.parseUnary()
+		return notNode{x}, err
+	case t.is(tokOp, "("):
+		x, err := ps.parseOr()
+		if err == nil {
+			err = ps.expect(tokOp, ")")
+		}
+		return x, err
+	case t.is(tokIdent, "has") && ps.peek().is(tokOp, "("):
+		ps.next()
+		name := ps.next()
+		f, err := lookupField(name)
+		if err == nil {
+			err = ps.expect(tokOp, ")")
+		}
+		return hasNode{f}, err
+	case t.kind == tokIdent:
+		return ps.parseComparison(t)
+	}
+	return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+}
+
+func (ps *filterParser) parseComparison(name filterToken) (filterNode, error) {
+	f, err := lookupField(name)
+	if err != nil {
+		return nil, err
+	}
+	opTok := ps.next()
+	op := opTok.text
+	if opTok.kind != tokOp && !opTok.is(tokIdent, "contains") {
+		return nil, fmt.Errorf("expected operator after %q at offset %d, got %s", name.text, opTok.pos, opTok)
+	}
+	lit := ps.next()
+
+	n := cmpNode{f: f, op: op}
+	switch f.kind {
+	case kindList:
+		if op != "contains" {
+			return nil, fmt.Errorf("%s is a %s; only contains is supported", name.text, f.kind)
+		}
+	case kindString:
+		if op != "==" && op != "!=" && op != "contains" {
+			return nil, fmt.Errorf("%s is a %s; operator %q is not supported", name.text, f.kind, op)
+		}
+	case kindNumber:
+		switch op {
+		case "==", "!=", "<", "<=", ">", ">=":
+		default:
+			return nil, fmt.Errorf("%s is a %s; operator %q is not supported", name.text, f.kind, op)
+		}
+		if lit.kind != tokNumber {
+			return nil, fmt.Errorf("%s needs a number at offset %d, got %s", name.text, lit.pos, lit)
+		}
+		n.num, _ = strconv.ParseFloat(lit.text, 64)
+		return n, nil
+	}
+	if lit.kind != tokString {
+		return nil, fmt.Errorf("%s needs a quoted string at offset %d, got %s", name.text, lit.pos, lit)
+	}
+	n.str = lit.text
+	return n, nil
+}
+
+func lookupField(t filterToken) (filterField, error) {
+	if t.kind != tokIdent {
+		return filterField{}, fmt.Errorf("expected field name at offset %d, got %s", t.pos, t)
+	}
+	f, ok := filterFields[t.text]
+	if !ok {
+		return filterField{}, fmt.Errorf("unknown field %q at offset %d", t.text, t.pos)
+	}
+	return f, nil
+}
+
+// ---- lexing ----
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+func (k tokKind) String() string {
+	return [...]string{"end of expression", "identifier", "string", "number", "operator"}[k]
+}
+
+type filterToken struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func (t filterToken) is(kind tokKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+func (t filterToken) String() string {
+	if t.kind == tokEOF {
+		return t.kind.String()
+	}
+	return fmt.Sprintf("%s %q", t.kind, t.text)
+}
+
+func lexFilter(src string) ([]filterToken, error) {
+	var toks []filterToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			s, err := strconv.QuotedPrefix(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			text, _ := strconv.Unquote(s)
+			toks = append(toks, filterToken{tokString, text, i})
+			i += len(s)
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(src) && (src[j] == '.' || (src[j] >= '0' && src[j] <= '9')) {
+				j++
+			}
+			if _, err := strconv.ParseFloat(src[i:j], 64); err != nil {
+				return nil, fmt.Errorf("invalid number %q at offset %d", src[i:j], i)
+			}
+			toks = append(toks, filterToken{tokNumber, src[i:j], i})
+			i = j
+		case isIdentByte(c):
+			j := i + 1
+			for j < len(src) && (isIdentByte(src[j]) || (src[j] >= '0' && src[j] <= '9')) {
+				j++
+			}
+			toks = append(toks, filterToken{tokIdent, src[i:j], i})
+			i = j
+		default:
+			op := ""
+			for _, o := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			toks = append(toks, filterToken{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, filterToken{kind: tokEOF, pos: len(src)}), nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
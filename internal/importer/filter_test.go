@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterMatch(t *testing.T) {
+	de := &OFFProduct{
+		Code:          "4000417025005",
+		ProductName:   "Apfelsaft",
+		Brands:        "Hohes C, Eckes",
+		CountriesTags: []string{"en:austria", "en:germany"},
+		Nutriments:    map[string]any{"energy-kcal_100g": 46.0, "proteins_100g": 0.1, "carbohydrates_100g": 10.5},
+		UniqueScansN:  120,
+	}
+	fr := &OFFProduct{
+		Code:          "3017620422003",
+		ProductName:   "Nutella",
+		CountriesTags: []string{"en:france"},
+		Nutriments:    map[string]any{"energy-kcal_100g": 539.0, "proteins_100g": 6.3, "fat_100g": 30.9, "carbohydrates_100g": 57.5},
+	}
+
+	tests := []struct {
+		expr   string
+		de, fr bool
+	}{
+		{`countries_tags contains "en:germany" && has(protein) && has(kcal)`, true, false},
+		{`countries_tags contains "en:germany" || countries_tags contains "en:france"`, true, true},
+		{`has(fat)`, false, true},
+		{`!has(fat)`, true, false},
+		{`kcal < 100`, true, false},
+		{`fat >= 0`, false, true}, // missing fat never compares
+		{`fat != 1`, false, true},
+		{`brands contains "Eckes"`, true, false},
+		{`code == "3017620422003"`, false, true},
+		{`product_name != "Nutella" && (unique_scans_n > 100 || kcal > 500)`, true, false},
+		{`has(unique_scans_n)`, true, false},
+		{`!(has(fat) && kcal > 500)`, true, false},
+	}
+	for _, tc := range tests {
+		f, err := ParseFilter(tc.expr)
+		if err != nil {
+			t.Fatalf("ParseFilter(%q): %v", tc.expr, err)
+		}
+		if got := f.Match(de); got != tc.de {
+			t.Errorf("%q on de = %v; want %v", tc.expr, got, tc.de)
+		}
+		if got := f.Match(fr); got != tc.fr {
+			t.Errorf("%q on fr = %v; want %v", tc.expr, got, tc.fr)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{``, "unexpected end of expression"},
+		{`countries contains "en:germany"`, `unknown field "countries"`},
+		{`countries_tags == "en:germany"`, "only contains is supported"},
+		{`kcal contains "1"`, `operator "contains" is not supported`},
+		{`kcal > "100"`, "needs a number"},
+		{`lang == de`, "needs a quoted string"},
+		{`lang < "de"`, `operator "<" is not supported`},
+		{`has(kcal`, "expected )"},
+		{`has(kcal) has(fat)`, `unexpected identifier "has"`},
+		{`lang == "de`, "unterminated string"},
+		{`kcal > 1.2.3`, "invalid number"},
+		{`kcal > 1 & fat > 1`, "unexpected character"},
+	}
+	for _, tc := range tests {
+		_, err := ParseFilter(tc.expr)
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("ParseFilter(%q) error = %v; want containing %q", tc.expr, err, tc.wantErr)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package importer
 
 import (
 	"bufio"
-	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,111 +17,236 @@ const (
 	batchSize     = 5_000
 )
 
-// Import reads a gzip-compressed JSONL Open Food Facts dump, builds a Pebble
-// KV store and Bleve full-text index inside outputDir, and returns the
-// resulting manifest.
+// Options controls an import run.
+type Options struct {
+	Verbose    bool            // log progress every 100k products
+	Duplicates DuplicatePolicy // which record wins for a repeated barcode; default DuplicateLatest
+
+	// ReportSamples is the number of offending lines sampled per skip reason
+	// into ReportFile; 0 means DefaultReportSamples.
+	ReportSamples int
+	// DryRun parses and validates the dump and writes only ReportFile; no
+	// stores, suggest index or manifest are built.
+	DryRun bool
+	// Filter, when set, keeps only records it matches; the rest are counted
+	// under the "filtered" skip reason.
+	Filter *Filter
+	// Shards splits the Bleve index into this many shards by barcode hash;
+	// 0 means 1.
+	Shards int
+	// Ingest writes Pebble records through an on-disk sort and SSTable
+	// ingestion (store.NewIngestBatch) instead of batch commits.
+	Ingest bool
+	// BarcodeIndex also writes the memory-mapped perfect-hash barcode index
+	// (Store.WriteBarcodeIndex), which the server then uses for lookups.
+	BarcodeIndex bool
+}
+
+// Import reads a JSONL Open Food Facts dump, builds a Pebble KV store, Bleve
+// full-text index and autocomplete FSTs inside outputDir, and returns the
+// resulting manifest. The dump may be gzip, zstd or bzip2 compressed or
+// plain, and StdinPath reads it from standard input. It also writes
+// ReportFile with sample lines for every skip reason and per-field nutriment
+// rejection counts.
+//
+// The text index is built offline once the whole dump has been read, leaving
+// one merged segment per shard; the manifest records its size and segment
+// count.
 //
 // Every product with a non-empty barcode is written to Pebble.
 // Products whose resolved name is non-empty are also indexed in Bleve.
 // Products with an empty or over-long barcode are skipped entirely.
-func Import(dumpPath, outputDir string, verbose bool) (*store.Manifest, error) {
+// When a barcode occurs more than once, opts.Duplicates picks the record that
+// is kept and the other is counted under the "duplicate_barcode" skip reason.
+func Import(dumpPath, outputDir string, opts Options) (*store.Manifest, error) {
+	if opts.Duplicates == "" {
+		opts.Duplicates = DuplicateLatest
+	}
+	if opts.ReportSamples <= 0 {
+		opts.ReportSamples = DefaultReportSamples
+	}
+	if opts.Shards <= 0 {
+		opts.Shards = 1
+	}
+
 	if err := os.MkdirAll(outputDir, 0o755); err != nil {
 		return nil, fmt.Errorf("create output dir: %w", err)
 	}
 
-	s, err := store.Create(outputDir)
-	if err != nil {
-		return nil, fmt.Errorf("create store: %w", err)
+	var (
+		s     *store.Store      // nil in dry-run mode
+		batch *store.WriteBatch // nil in dry-run mode
+	)
+	if !opts.DryRun {
+		var err error
+		if s, err = store.CreateOffline(outputDir, opts.Shards); err != nil {
+			return nil, fmt.Errorf("create store: %w", err)
+		}
+		defer s.Close()
+		if opts.Ingest {
+			if batch, err = s.NewIngestBatch(); err != nil {
+				return nil, fmt.Errorf("create ingest batch: %w", err)
+			}
+		} else {
+			batch = s.NewWriteBatch()
+		}
 	}
-	defer s.Close()
 
-	f, err := os.Open(dumpPath)
+	in, err := openDump(dumpPath)
 	if err != nil {
-		return nil, fmt.Errorf("open dump: %w", err)
+		return nil, err
 	}
-	defer f.Close()
+	defer in.Close()
+	slog.Info("reading dump", "path", dumpPath, "compression", in.Compression, "size_bytes", in.Size)
 
-	gz, err := gzip.NewReader(f)
+	report, err := newReportWriter(outputDir, opts.ReportSamples)
 	if err != nil {
-		return nil, fmt.Errorf("open gzip reader: %w", err)
+		return nil, err
 	}
-	defer gz.Close()
+	defer report.f.Close()
 
 	var (
-		productCount int64
-		indexedCount int64
-		skippedCount int64
-		skipReasons  = make(map[string]int64)
-		startTime    = time.Now()
+		productCount    int64
+		indexedCount    int64
+		skippedCount    int64
+		lineNo          int64
+		line            []byte
+		skipReasons     = make(map[string]int64)
+		fieldRejections = make(map[string]int64)
+		qualityFlags    = make(map[string]int64)
+		seen            = make(map[string]seenProduct)
+		startTime       = time.Now()
 	)
 
-	batch := s.NewWriteBatch()
+	skip := func(reason, detail string) error {
+		skippedCount++
+		skipReasons[reason]++
+		return report.sample(reason, lineNo, line, detail)
+	}
 
-	scanner := bufio.NewScanner(gz)
+	suggest := store.NewSuggestBuilder()
+
+	scanner := bufio.NewScanner(in)
 	// Some OFF lines can be very large; allocate a generous buffer.
 	buf := make([]byte, 0, 4*1024*1024)
 	scanner.Buffer(buf, 16*1024*1024)
 
 	for scanner.Scan() {
-		line := scanner.Bytes()
+		lineNo++
+		line = scanner.Bytes()
 		if len(line) == 0 {
 			continue
 		}
 
 		var off OFFProduct
 		if err := json.Unmarshal(line, &off); err != nil {
-			skippedCount++
-			skipReasons["parse_error"]++
 			slog.Debug("json unmarshal error, skipping line", "error", err)
+			if err := skip("parse_error", err.Error()); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
 		barcode := off.Code
 		if barcode == "" {
-			skippedCount++
-			skipReasons["empty_barcode"]++
+			if err := skip("empty_barcode", ""); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
 		if len(barcode) > maxBarcodeLen {
-			skippedCount++
-			skipReasons["barcode_too_long"]++
+			if err := skip("barcode_too_long", ""); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if opts.Filter != nil && !opts.Filter.Match(&off) {
+			if err := skip("filtered", ""); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
+		for _, r := range off.Rejections() {
+			fieldRejections[r]++
+		}
+
 		name := off.Name()
 
 		p := store.Product{
-			Barcode:  barcode,
-			Name:     name,
-			Kcal100g: off.Kcal100g(),
-			Protein:  off.Protein100g(),
-			Fat:      off.Fat100g(),
-			Carbs:    off.Carbs100g(),
+			Barcode:    barcode,
+			Name:       name,
+			Kcal100g:   off.Kcal100g(),
+			Protein:    off.Protein100g(),
+			Fat:        off.Fat100g(),
+			Carbs:      off.Carbs100g(),
+			Popularity: off.Popularity(),
+			Brand:      off.Brand(),
+			Lang:       off.Lang,
+		}
+		entry := seenProduct{
+			line:     lineNo,
+			modified: off.LastModifiedT,
+			complete: nutrimentCount(p.Kcal100g, p.Protein, p.Fat, p.Carbs),
+		}
+		p.Kcal100g, p.Quality = assessQuality(p.Kcal100g, p.Protein, p.Fat, p.Carbs)
+		entry.quality = p.Quality
+
+		prev, dup := seen[barcode]
+		if dup {
+			// One of the two records is dropped either way.
+			replace := opts.Duplicates.prefer(entry, prev)
+			detail := fmt.Sprintf("kept line %d", prev.line)
+			if replace {
+				detail = fmt.Sprintf("replaced line %d", prev.line)
+			}
+			if err := skip("duplicate_barcode", detail); err != nil {
+				return nil, err
+			}
+			if !replace {
+				continue
+			}
+			// Undo the replaced record's contributions; its Pebble value and
+			// Bleve document are overwritten below.
+			for _, f := range prev.quality.Names() {
+				qualityFlags[f]--
+			}
+			if prev.name != "" {
+				indexedCount--
+				suggest.Remove(prev.name)
+			}
+		} else {
+			productCount++
 		}
 
-		batch.Put(p)
-		productCount++
+		if batch != nil {
+			batch.Put(p)
+			if name == "" && prev.name != "" {
+				batch.Unindex(barcode)
+			}
+		}
 		if name != "" {
+			entry.name = name
 			indexedCount++
+			suggest.Add(name)
+		}
+		seen[barcode] = entry
+		for _, f := range p.Quality.Names() {
+			qualityFlags[f]++
 		}
 
-		if batch.Len() >= batchSize {
+		if batch != nil && batch.Len() >= batchSize {
 			if err := batch.Flush(); err != nil {
 				return nil, fmt.Errorf("batch flush: %w", err)
 			}
 		}
 
-		if verbose && productCount%100_000 == 0 {
-			elapsed := time.Since(startTime)
-			rate := float64(productCount) / elapsed.Seconds()
-			slog.Info("import progress",
-				"products", productCount,
-				"indexed", indexedCount,
-				"skipped", skippedCount,
-				"rate_per_s", int(rate),
-				"elapsed", elapsed.Round(time.Second),
-			)
+		// A replaced duplicate leaves productCount unchanged; only a new
+		// product can reach the next progress mark.
+		if opts.Verbose && !dup && productCount%100_000 == 0 {
+			logProgress(in, startTime, productCount, indexedCount, skippedCount)
 		}
 	}
 
@@ -130,23 +254,81 @@ func Import(dumpPath, outputDir string, verbose bool) (*store.Manifest, error) {
 		return nil, fmt.Errorf("scanner error: %w", err)
 	}
 
+	if err := report.finish(lineNo, skipReasons, fieldRejections); err != nil {
+		return nil, err
+	}
+
+	m := &store.Manifest{
+		BuildTime:       time.Now().UTC(),
+		DumpSource:      dumpSource(dumpPath),
+		Filter:          opts.Filter.String(),
+		ProductCount:    productCount,
+		IndexedCount:    indexedCount,
+		SkippedCount:    skippedCount,
+		SchemaVersion:   store.SchemaVersion,
+		IndexShards:     opts.Shards,
+		SkipReasons:     skipReasons,
+		FieldRejections: fieldRejections,
+		QualityFlags:    qualityFlags,
+	}
+	if opts.DryRun {
+		return m, nil
+	}
+
 	if err := batch.Close(); err != nil {
 		return nil, fmt.Errorf("final batch flush: %w", err)
 	}
 
-	m := &store.Manifest{
-		BuildTime:     time.Now().UTC(),
-		DumpSource:    dumpPath,
-		ProductCount:  productCount,
-		IndexedCount:  indexedCount,
-		SkippedCount:  skippedCount,
-		SchemaVersion: 1,
-		SkipReasons:   skipReasons,
+	if m.IndexBytes, m.IndexSegments, err = store.IndexFootprint(outputDir); err != nil {
+		return nil, err
 	}
 
+	if opts.BarcodeIndex {
+		if err := s.WriteBarcodeIndex(); err != nil {
+			return nil, err
+		}
+		m.BarcodeIndex = true
+	}
+
+	if err := suggest.Write(outputDir); err != nil {
+		return nil, fmt.Errorf("write suggest index: %w", err)
+	}
+	m.SuggestCount = int64(suggest.Len())
+
 	if err := store.WriteManifest(outputDir, m); err != nil {
 		return nil, fmt.Errorf("write manifest: %w", err)
 	}
 
 	return m, nil
 }
+
+// logProgress logs import throughput. When the compressed dump size is known
+// it adds the share of it consumed so far and an ETA extrapolated from it.
+func logProgress(in *dumpInput, start time.Time, products, indexed, skipped int64) {
+	elapsed := time.Since(start)
+	attrs := []any{
+		"products", products,
+		"indexed", indexed,
+		"skipped", skipped,
+		"rate_per_s", int(float64(products) / elapsed.Seconds()),
+		"elapsed", elapsed.Round(time.Second),
+	}
+	if in.Size > 0 {
+		if frac := float64(in.Consumed()) / float64(in.Size); frac > 0 {
+			eta := time.Duration(float64(elapsed) * (1 - frac) / frac)
+			attrs = append(attrs,
+				"percent", fmt.Sprintf("%.1f", 100*frac),
+				"eta", eta.Round(time.Second),
+			)
+		}
+	}
+	slog.Info("import progress", attrs...)
+}
+
+// dumpSource is the manifest's record of where the dump came from.
+func dumpSource(path string) string {
+	if path == StdinPath {
+		return "stdin"
+	}
+	return path
+}
@@ -0,0 +1,267 @@
+package importer
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/korjavin/fastfooddb/internal/store"
+)
+
+// writeDump writes lines as a gzip-compressed JSONL dump and returns its path.
+func writeDump(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dump.jsonl.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(strings.Join(lines, "\n") + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImport_DuplicateBarcodes(t *testing.T) {
+	dump := writeDump(t,
+		`{"code":"111","product_name":"Old Cola","last_modified_t":100,"nutriments":{"energy-kcal_100g":42,"proteins_100g":0,"fat_100g":0,"carbohydrates_100g":10.6}}`,
+		`{"code":"222","product_name":"Milk","last_modified_t":100,"nutriments":{"energy-kcal_100g":64,"proteins_100g":3.3,"fat_100g":3.6,"carbohydrates_100g":4.8}}`,
+		`{"code":"111","product_name":"New Cola","last_modified_t":200,"nutriments":{"energy-kcal_100g":42}}`,
+		`{"code":"222","last_modified_t":300,"nutriments":{"proteins_100g":3.3,"fat_100g":3.6,"carbohydrates_100g":4.8}}`,
+		`{"code":"111","product_name":"Stale Cola","last_modified_t":50}`,
+	)
+
+	tests := []struct {
+		pol      DuplicatePolicy
+		name111  string
+		indexed  int64
+		inferred int64
+	}{
+		// 222's newest record has no name, so it leaves the text index.
+		{DuplicateLatest, "New Cola", 1, 1},
+		{DuplicateComplete, "Old Cola", 2, 0},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.pol), func(t *testing.T) {
+			out := t.TempDir()
+			m, err := Import(dump, out, Options{Duplicates: tc.pol, Shards: 2})
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+			if m.ProductCount != 2 || m.SkippedCount != 3 || m.SkipReasons["duplicate_barcode"] != 3 {
+				t.Errorf("counts = products %d, skipped %d, reasons %v; want 2, 3, duplicate_barcode:3",
+					m.ProductCount, m.SkippedCount, m.SkipReasons)
+			}
+			if m.IndexedCount != tc.indexed {
+				t.Errorf("IndexedCount = %d; want %d", m.IndexedCount, tc.indexed)
+			}
+			// Offline builds leave at most one segment per shard.
+			if m.IndexBytes == 0 || m.IndexSegments < 1 || m.IndexSegments > m.IndexShards {
+				t.Errorf("index footprint = %d bytes, %d segments over %d shards",
+					m.IndexBytes, m.IndexSegments, m.IndexShards)
+			}
+			if m.QualityFlags["kcal_inferred"] != tc.inferred {
+				t.Errorf("QualityFlags = %v; want kcal_inferred:%d", m.QualityFlags, tc.inferred)
+			}
+
+			s, err := store.OpenReadOnly(out)
+			if err != nil {
+				t.Fatalf("OpenReadOnly: %v", err)
+			}
+			defer s.Close()
+
+			p, ok, err := s.Get("111")
+			if err != nil || !ok || p.Name != tc.name111 {
+				t.Errorf("Get(111) = %q, %v, %v; want %q", p.Name, ok, err, tc.name111)
+			}
+			results, err := s.Search("milk", 10)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if want := int(tc.indexed) - 1; len(results) != want {
+				t.Errorf("Search(milk) returned %d results; want %d", len(results), want)
+			}
+			if got, _ := s.Suggest("stale", 5); len(got) != 0 {
+				t.Errorf("Suggest(stale) = %v; want none", got)
+			}
+		})
+	}
+}
+
+func TestImport_Ingest(t *testing.T) {
+	dump := writeDump(t,
+		`{"code":"333","product_name":"Oat Milk","last_modified_t":100,"nutriments":{"energy-kcal_100g":46}}`,
+		`{"code":"111","product_name":"Old Cola","last_modified_t":100,"nutriments":{"energy-kcal_100g":42}}`,
+		`{"code":"222","product_name":"Milk","last_modified_t":100,"nutriments":{"energy-kcal_100g":64,"proteins_100g":3.3}}`,
+		`{"code":"111","product_name":"New Cola","last_modified_t":200,"nutriments":{"energy-kcal_100g":42}}`,
+	)
+
+	var manifests [2]*store.Manifest
+	var stores [2]*store.Store
+	for i, ingest := range []bool{false, true} {
+		out := t.TempDir()
+		m, err := Import(dump, out, Options{Shards: 2, Ingest: ingest})
+		if err != nil {
+			t.Fatalf("Import(ingest=%v): %v", ingest, err)
+		}
+		if _, err := os.Stat(filepath.Join(out, "ingest-tmp")); !os.IsNotExist(err) {
+			t.Errorf("ingest=%v: scratch dir left behind (stat err %v)", ingest, err)
+		}
+		s, err := store.OpenReadOnly(out)
+		if err != nil {
+			t.Fatalf("OpenReadOnly: %v", err)
+		}
+		defer s.Close()
+		manifests[i], stores[i] = m, s
+	}
+
+	batch, ingest := manifests[0], manifests[1]
+	if ingest.ProductCount != batch.ProductCount || ingest.IndexedCount != batch.IndexedCount ||
+		ingest.SkippedCount != batch.SkippedCount {
+		t.Errorf("ingest counts = %d/%d/%d; batch import = %d/%d/%d",
+			ingest.ProductCount, ingest.IndexedCount, ingest.SkippedCount,
+			batch.ProductCount, batch.IndexedCount, batch.SkippedCount)
+	}
+	for _, code := range []string{"111", "222", "333", "444"} {
+		want, wantOK, err := stores[0].Get(code)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", code, err)
+		}
+		got, ok, err := stores[1].Get(code)
+		if err != nil || ok != wantOK || got.Name != want.Name {
+			t.Errorf("ingest Get(%s) = %q, %v, %v; batch import = %q, %v", code, got.Name, ok, err, want.Name, wantOK)
+		}
+	}
+	results, err := stores[1].Search("milk", 10)
+	if err != nil || len(results) != 2 {
+		t.Errorf("ingest Search(milk) = %d results, %v; want 2", len(results), err)
+	}
+}
+
+func TestImport_ReportAndDryRun(t *testing.T) {
+	dump := writeDump(t,
+		`{"code":"111","product_name":"Cola","nutriments":{"proteins_100g":120}}`,
+		`not json`,
+		`{"product_name":"No Code"}`,
+		`{"product_name":"No Code Either"}`,
+		`{"product_name":"Still No Code"}`,
+		``,
+		`{"code":"222","product_name":"Milk","nutriments":{"fat_100g":"n/a","energy-kcal_100g":-5}}`,
+	)
+
+	out := t.TempDir()
+	m, err := Import(dump, out, Options{DryRun: true, ReportSamples: 2})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if m.ProductCount != 2 || m.SkippedCount != 4 {
+		t.Errorf("counts = products %d, skipped %d; want 2, 4", m.ProductCount, m.SkippedCount)
+	}
+	wantRejections := map[string]int64{"protein_out_of_range": 1, "fat_not_numeric": 1, "kcal_out_of_range": 1}
+	if !reflect.DeepEqual(m.FieldRejections, wantRejections) {
+		t.Errorf("FieldRejections = %v; want %v", m.FieldRejections, wantRejections)
+	}
+
+	// Dry-run builds nothing but the report.
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != ReportFile {
+		t.Errorf("output dir = %v; want only %s", entries, ReportFile)
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, ReportFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var samples []reportSample
+	var summary reportSummary
+	for _, l := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var s reportSample
+		if err := json.Unmarshal([]byte(l), &s); err != nil {
+			t.Fatalf("report line %q: %v", l, err)
+		}
+		if s.Type == "summary" {
+			if err := json.Unmarshal([]byte(l), &summary); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		samples = append(samples, s)
+	}
+
+	wantLines := map[string][]int64{"parse_error": {2}, "empty_barcode": {3, 4}}
+	gotLines := make(map[string][]int64)
+	for _, s := range samples {
+		gotLines[s.Reason] = append(gotLines[s.Reason], s.Line)
+	}
+	if !reflect.DeepEqual(gotLines, wantLines) {
+		t.Errorf("sampled lines = %v; want %v", gotLines, wantLines)
+	}
+	if summary.Lines != 7 || summary.SkipReasons["empty_barcode"] != 3 || summary.FieldRejections["fat_not_numeric"] != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestImport_Filter(t *testing.T) {
+	dump := writeDump(t,
+		`{"code":"111","product_name":"Apfelsaft","countries_tags":["en:germany"],"nutriments":{"energy-kcal_100g":46,"proteins_100g":0.1}}`,
+		`{"code":"222","product_name":"Jus de pomme","countries_tags":["en:france"],"nutriments":{"energy-kcal_100g":46,"proteins_100g":0.1}}`,
+		`{"code":"333","product_name":"Apfelschorle","countries_tags":["en:germany"],"nutriments":{"energy-kcal_100g":24}}`,
+	)
+	f, err := ParseFilter(`countries_tags contains "en:germany" && has(protein) && has(kcal)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Import(dump, t.TempDir(), Options{Filter: f})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if m.ProductCount != 1 || m.SkipReasons["filtered"] != 2 || m.Filter != f.String() {
+		t.Errorf("manifest = products %d, reasons %v, filter %q", m.ProductCount, m.SkipReasons, m.Filter)
+	}
+}
+
+func TestImport_BarcodeIndex(t *testing.T) {
+	dump := writeDump(t,
+		`{"code":"111","product_name":"Old Cola","last_modified_t":100}`,
+		`{"code":"222","product_name":"Milk","nutriments":{"energy-kcal_100g":64}}`,
+		`{"code":"111","product_name":"New Cola","last_modified_t":200}`,
+	)
+	out := t.TempDir()
+	m, err := Import(dump, out, Options{BarcodeIndex: true, Ingest: true})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if !m.BarcodeIndex {
+		t.Error("manifest does not record the barcode index")
+	}
+
+	s, err := store.OpenReadOnly(out)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer s.Close()
+	if p, ok, err := s.Get("111"); err != nil || !ok || p.Name != "New Cola" {
+		t.Errorf("Get(111) = %q, %v, %v; want New Cola", p.Name, ok, err)
+	}
+	if p, ok, err := s.Get("222"); err != nil || !ok || p.Kcal100g != 64 {
+		t.Errorf("Get(222) = %v, %v, %v; want 64 kcal", p.Kcal100g, ok, err)
+	}
+	if _, ok, err := s.Get("333"); err != nil || ok {
+		t.Errorf("Get(333) = %v, %v; want a miss", ok, err)
+	}
+}
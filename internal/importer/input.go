@@ -0,0 +1,134 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// StdinPath is the dump path that makes Import read from standard input.
+const StdinPath = "-"
+
+// Compression formats recognised by their magic bytes.
+const (
+	compressionNone  = "none"
+	compressionGzip  = "gzip"
+	compressionZstd  = "zstd"
+	compressionBzip2 = "bzip2"
+)
+
+var (
+	magicGzip  = []byte{0x1f, 0x8b}
+	magicZstd  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	magicBzip2 = []byte("BZh")
+)
+
+// dumpInput is an opened, decompressing dump reader.
+type dumpInput struct {
+	io.Reader          // decompressed JSONL
+	Compression string // one of the compression* names
+	Size        int64  // compressed size in bytes, 0 when unknown (pipes)
+	consumed    *countingReader
+	close       func() error
+}
+
+// Consumed returns the number of compressed bytes read so far.
+func (in *dumpInput) Consumed() int64 {
+	return in.consumed.n
+}
+
+// Close releases the decompressor and the underlying file.
+func (in *dumpInput) Close() error {
+	return in.close()
+}
+
+// countingReader counts bytes read from the raw (compressed) dump.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// openDump opens path, or standard input for StdinPath, and detects the
+// compression from its first bytes so gzip, zstd, bzip2 and plain JSONL all
+// work without flags.
+func openDump(path string) (*dumpInput, error) {
+	var f *os.File
+	if path == StdinPath {
+		f = os.Stdin
+	} else {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, fmt.Errorf("open dump: %w", err)
+		}
+	}
+	closeFile := func() error {
+		if f == os.Stdin {
+			return nil
+		}
+		return f.Close()
+	}
+
+	var size int64
+	if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+		size = fi.Size()
+	}
+
+	in, err := newDumpInput(f, size)
+	if err != nil {
+		_ = closeFile()
+		return nil, err
+	}
+	decClose := in.close
+	in.close = func() error {
+		err := decClose()
+		if cerr := closeFile(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+	return in, nil
+}
+
+// newDumpInput wraps r in the decompressor matching its magic bytes.
+func newDumpInput(r io.Reader, size int64) (*dumpInput, error) {
+	cr := &countingReader{r: r}
+	br := bufio.NewReaderSize(cr, 64*1024)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read dump header: %w", err)
+	}
+
+	in := &dumpInput{Size: size, consumed: cr, close: func() error { return nil }}
+	switch {
+	case bytes.HasPrefix(magic, magicGzip):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("open gzip reader: %w", err)
+		}
+		in.Reader, in.Compression, in.close = gz, compressionGzip, gz.Close
+	case bytes.HasPrefix(magic, magicZstd):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("open zstd reader: %w", err)
+		}
+		in.Reader, in.Compression = zr, compressionZstd
+		in.close = func() error { zr.Close(); return nil }
+	case bytes.HasPrefix(magic, magicBzip2):
+		in.Reader, in.Compression = bzip2.NewReader(br), compressionBzip2
+	default:
+		in.Reader, in.Compression = br, compressionNone
+	}
+	return in, nil
+}
@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const inputJSONL = "{\"code\":\"1\"}\n"
+
+// bzip2Fixture is inputJSONL compressed with bzip2; the standard library
+// has no bzip2 writer.
+var bzip2Fixture = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x64, 0x42,
+	0x5b, 0x80, 0x00, 0x00, 0x05, 0xd9, 0x80, 0x00, 0x10, 0x10, 0x00, 0x20,
+	0x10, 0x0e, 0x00, 0x80, 0x0a, 0x20, 0x00, 0x22, 0x03, 0x41, 0xea, 0x10,
+	0x03, 0x01, 0x69, 0x98, 0xe0, 0x0b, 0xc5, 0xdc, 0x91, 0x4e, 0x14, 0x24,
+	0x19, 0x10, 0x96, 0xe0, 0x00,
+}
+
+func TestOpenDump_DetectsCompression(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(inputJSONL))
+	gw.Close()
+
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zst := zw.EncodeAll([]byte(inputJSONL), nil)
+	zw.Close()
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"gzip", gz.Bytes(), compressionGzip},
+		{"zstd", zst, compressionZstd},
+		{"bzip2", bzip2Fixture, compressionBzip2},
+		{"plain", []byte(inputJSONL), compressionNone},
+		{"empty", nil, compressionNone},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dump")
+			if err := os.WriteFile(path, tc.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			in, err := openDump(path)
+			if err != nil {
+				t.Fatalf("openDump: %v", err)
+			}
+			defer in.Close()
+
+			if in.Compression != tc.want {
+				t.Errorf("Compression = %q; want %q", in.Compression, tc.want)
+			}
+			got, err := io.ReadAll(in)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if len(tc.data) > 0 && string(got) != inputJSONL {
+				t.Errorf("content = %q; want %q", got, inputJSONL)
+			}
+			if in.Size != int64(len(tc.data)) || in.Consumed() != int64(len(tc.data)) {
+				t.Errorf("Size = %d, Consumed = %d; want %d", in.Size, in.Consumed(), len(tc.data))
+			}
+		})
+	}
+}
+
+func TestOpenDump_Stdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = orig }()
+
+	go func() {
+		w.Write([]byte(inputJSONL))
+		w.Close()
+	}()
+
+	in, err := openDump(StdinPath)
+	if err != nil {
+		t.Fatalf("openDump(stdin): %v", err)
+	}
+	defer in.Close()
+	got, _ := io.ReadAll(in)
+	if string(got) != inputJSONL || in.Size != 0 {
+		t.Errorf("stdin: content %q, size %d; want %q, 0 (pipe size unknown)", got, in.Size, inputJSONL)
+	}
+}
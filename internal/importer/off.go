@@ -3,6 +3,7 @@ package importer
 import (
 	"fmt"
 	"math"
+	"strings"
 )
 
 // OFFProduct is the minimal subset of an Open Food Facts JSONL record.
@@ -12,6 +13,13 @@ type OFFProduct struct {
 	ProductNameEn    string         `json:"product_name_en"`
 	GenericName      string         `json:"generic_name"`
 	ShortDescription string         `json:"short_description"`
+	Brands           string         `json:"brands"`
+	Lang             string         `json:"lang"`
+	UniqueScansN     float64        `json:"unique_scans_n"`
+	PopularityKey    float64        `json:"popularity_key"`
+	LastModifiedT    int64          `json:"last_modified_t"`
+	CountriesTags    []string       `json:"countries_tags"`
+	CategoriesTags   []string       `json:"categories_tags"`
 	Nutriments       map[string]any `json:"nutriments"`
 }
 
@@ -67,6 +75,59 @@ func (p *OFFProduct) Carbs100g() float32 {
 	return float32(math.NaN())
 }
 
+// Brand returns the first entry of the comma-separated brands field.
+func (p *OFFProduct) Brand() string {
+	first, _, _ := strings.Cut(p.Brands, ",")
+	return strings.TrimSpace(first)
+}
+
+// popularityKeyOnly is the score of a product that OFF ranks via
+// popularity_key but that has no unique_scans_n: above unknown products, below
+// a single real scan (ln 2 ≈ 0.69).
+const popularityKeyOnly = 0.5
+
+// Popularity returns the log-damped scan count ln(1 + unique_scans_n), so a
+// product with thousands of scans ranks above a one-off upload without
+// drowning out text relevance.
+func (p *OFFProduct) Popularity() float32 {
+	if n := p.UniqueScansN; n > 0 && !math.IsInf(n, 0) {
+		return float32(math.Log1p(n))
+	}
+	if p.PopularityKey > 0 {
+		return popularityKeyOnly
+	}
+	return 0
+}
+
+// Rejections names the nutriments present in the record that the accessors
+// above discard: "<field>_out_of_range" when validateNutriment rejects the
+// value and "<field>_not_numeric" when it cannot be parsed. The kJ fallback is
+// only checked when no kcal value was given.
+func (p *OFFProduct) Rejections() []string {
+	var out []string
+	check := func(field, key string, scale float64, min, max float32) (present bool) {
+		raw, ok := p.Nutriments[key]
+		if !ok || raw == nil {
+			return false
+		}
+		v, ok := extractFloat(p.Nutriments, key)
+		switch {
+		case !ok:
+			out = append(out, field+"_not_numeric")
+		case math.IsNaN(float64(validateNutriment(float32(v*scale), min, max))):
+			out = append(out, field+"_out_of_range")
+		}
+		return true
+	}
+	if !check("kcal", "energy-kcal_100g", 1, 0, 10000) {
+		check("kcal", "energy-kj_100g", 1/4.184, 0, 10000)
+	}
+	check("protein", "proteins_100g", 1, 0, 100)
+	check("fat", "fat_100g", 1, 0, 100)
+	check("carbs", "carbohydrates_100g", 1, 0, 100)
+	return out
+}
+
 // validateNutriment returns NaN if v is outside [min, max], otherwise v.
 func validateNutriment(v float32, min, max float32) float32 {
 	if math.IsNaN(float64(v)) || v < min || v > max {
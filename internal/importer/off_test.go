@@ -2,6 +2,7 @@ package importer
 
 import (
 	"math"
+	"reflect"
 	"testing"
 )
 
@@ -80,3 +81,43 @@ func TestOFFProductKcalFallback(t *testing.T) {
 		t.Errorf("Kcal100g() kj fallback = %v; want ~100", got)
 	}
 }
+
+func TestOFFProductPopularity(t *testing.T) {
+	tests := []struct {
+		name string
+		p    OFFProduct
+		want float32
+	}{
+		{"no data", OFFProduct{}, 0},
+		{"scans", OFFProduct{UniqueScansN: math.E - 1}, 1},
+		{"scans win over key", OFFProduct{UniqueScansN: math.E - 1, PopularityKey: 1e10}, 1},
+		{"key only", OFFProduct{PopularityKey: 1e10}, popularityKeyOnly},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.p.Popularity(); math.Abs(float64(got-tc.want)) > 1e-6 {
+				t.Errorf("Popularity() = %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOFFProductRejections(t *testing.T) {
+	p := &OFFProduct{
+		Nutriments: map[string]any{
+			"energy-kj_100g":     float64(50000), // > 10000 kcal after conversion
+			"proteins_100g":      float64(120),
+			"fat_100g":           "n/a",
+			"carbohydrates_100g": float64(12),
+		},
+	}
+	got := p.Rejections()
+	want := []string{"kcal_out_of_range", "protein_out_of_range", "fat_not_numeric"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Rejections() = %v; want %v", got, want)
+	}
+
+	if got := (&OFFProduct{}).Rejections(); got != nil {
+		t.Errorf("Rejections() on empty record = %v; want nil", got)
+	}
+}
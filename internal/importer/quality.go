@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"math"
+
+	"github.com/korjavin/fastfooddb/internal/store"
+)
+
+const (
+	// minEnergyConsistency is the score below which kcal and macros disagree.
+	minEnergyConsistency = 0.75
+	// energyMismatchSlack ignores disagreements smaller than this many kcal,
+	// so low-energy products are not flagged over rounding.
+	energyMismatchSlack = 40
+	// macrosSumSlack tolerates rounding when checking protein+fat+carbs ≤ 100g.
+	macrosSumSlack = 1
+)
+
+// atwaterKcal estimates energy from macros with the 4/4/9 factors.
+func atwaterKcal(protein, fat, carbs float32) float64 {
+	return 4*float64(protein) + 4*float64(carbs) + 9*float64(fat)
+}
+
+// energyConsistency scores how well kcal agrees with the macros, from 1
+// (identical) down to 0. Fibre, alcohol and polyols make real products
+// deviate somewhat. Returns NaN when any value is missing.
+func energyConsistency(kcal, protein, fat, carbs float32) float64 {
+	for _, v := range []float32{kcal, protein, fat, carbs} {
+		if math.IsNaN(float64(v)) {
+			return math.NaN()
+		}
+	}
+	est := atwaterKcal(protein, fat, carbs)
+	hi := math.Max(float64(kcal), est)
+	if hi == 0 {
+		return 1
+	}
+	return 1 - math.Abs(float64(kcal)-est)/hi
+}
+
+// assessQuality checks a product's nutrients for plausibility. When kcal is
+// missing but all macros are present it is inferred from them. It returns
+// the (possibly inferred) kcal and the quality flags to store.
+func assessQuality(kcal, protein, fat, carbs float32) (float32, store.QualityFlags) {
+	var flags store.QualityFlags
+	haveMacros := !math.IsNaN(float64(protein)) && !math.IsNaN(float64(fat)) && !math.IsNaN(float64(carbs))
+
+	if haveMacros && float64(protein)+float64(fat)+float64(carbs) > 100+macrosSumSlack {
+		flags |= store.QualityMacrosExceed100g
+	}
+
+	if math.IsNaN(float64(kcal)) {
+		if haveMacros {
+			kcal = float32(atwaterKcal(protein, fat, carbs))
+			flags |= store.QualityKcalInferred
+		}
+		return kcal, flags
+	}
+
+	if score := energyConsistency(kcal, protein, fat, carbs); score < minEnergyConsistency &&
+		math.Abs(float64(kcal)-atwaterKcal(protein, fat, carbs)) > energyMismatchSlack {
+		flags |= store.QualityEnergyMismatch
+	}
+	return kcal, flags
+}
@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestAssessQuality(t *testing.T) {
+	nan := float32(math.NaN())
+
+	tests := []struct {
+		name                  string
+		kcal, protein, fat, c float32
+		wantKcal              float32 // ignored when NaN is expected
+		wantNaN               bool
+		wantFlags             []string
+	}{
+		{"consistent", 389, 13, 7, 60, 389, false, nil},
+		{"kj entered as kcal", 1628, 13, 7, 60, 1628, false, []string{"energy_mismatch"}},
+		{"small absolute difference", 20, 0, 0, 2, 20, false, nil},
+		{"kcal inferred", nan, 10, 10, 10, 170, false, []string{"kcal_inferred"}},
+		{"kcal missing, macro missing", nan, 10, nan, 10, 0, true, nil},
+		{"macros exceed 100g", 850, 50, 50, 50, 850, false, []string{"macros_exceed_100g"}},
+		{"macros sum within rounding", 400, 50, 0.5, 50.4, 400, false, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kcal, flags := assessQuality(tc.kcal, tc.protein, tc.fat, tc.c)
+			if tc.wantNaN {
+				if !math.IsNaN(float64(kcal)) {
+					t.Errorf("kcal = %v; want NaN", kcal)
+				}
+			} else if kcal != tc.wantKcal {
+				t.Errorf("kcal = %v; want %v", kcal, tc.wantKcal)
+			}
+			if got := flags.Names(); !reflect.DeepEqual(got, tc.wantFlags) {
+				t.Errorf("flags = %v; want %v", got, tc.wantFlags)
+			}
+		})
+	}
+}
+
+func TestEnergyConsistency(t *testing.T) {
+	if got := energyConsistency(0, 0, 0, 0); got != 1 {
+		t.Errorf("all zero = %v; want 1", got)
+	}
+	if got := energyConsistency(100, 0, 0, 25); got != 1 {
+		t.Errorf("exact = %v; want 1", got)
+	}
+	if got := energyConsistency(50, 0, 0, 25); got != 0.5 {
+		t.Errorf("half = %v; want 0.5", got)
+	}
+	if got := energyConsistency(float32(math.NaN()), 0, 0, 25); !math.IsNaN(got) {
+		t.Errorf("missing kcal = %v; want NaN", got)
+	}
+}
@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// ReportFile is the rejection report written next to the manifest.
+	ReportFile = "import_report.jsonl"
+
+	// DefaultReportSamples is the number of offending lines kept per skip reason.
+	DefaultReportSamples = 5
+
+	// maxSampleBytes truncates sampled records; OFF lines can be megabytes.
+	maxSampleBytes = 4096
+)
+
+// reportSample is one offending dump line in the report.
+type reportSample struct {
+	Type      string `json:"type"` // "sample"
+	Reason    string `json:"reason"`
+	Line      int64  `json:"line"`
+	Detail    string `json:"detail,omitempty"`
+	Record    string `json:"record"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// reportSummary is the last line of the report.
+type reportSummary struct {
+	Type            string           `json:"type"` // "summary"
+	Lines           int64            `json:"lines"`
+	SkipReasons     map[string]int64 `json:"skip_reasons"`
+	FieldRejections map[string]int64 `json:"field_rejections"`
+}
+
+// reportWriter streams up to maxPerReason samples per skip reason to
+// ReportFile, followed by a summary line.
+type reportWriter struct {
+	f            *os.File
+	w            *bufio.Writer
+	enc          *json.Encoder
+	maxPerReason int
+	sampled      map[string]int
+}
+
+func newReportWriter(dir string, maxPerReason int) (*reportWriter, error) {
+	f, err := os.Create(filepath.Join(dir, ReportFile))
+	if err != nil {
+		return nil, fmt.Errorf("create report: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	return &reportWriter{
+		f:            f,
+		w:            w,
+		enc:          json.NewEncoder(w),
+		maxPerReason: maxPerReason,
+		sampled:      make(map[string]int),
+	}, nil
+}
+
+// sample records line as an example of reason unless enough are kept already.
+func (r *reportWriter) sample(reason string, lineNo int64, line []byte, detail string) error {
+	if r.sampled[reason] >= r.maxPerReason {
+		return nil
+	}
+	r.sampled[reason]++
+	s := reportSample{Type: "sample", Reason: reason, Line: lineNo, Detail: detail}
+	if len(line) > maxSampleBytes {
+		line, s.Truncated = line[:maxSampleBytes], true
+	}
+	s.Record = string(line)
+	return r.enc.Encode(s)
+}
+
+// finish writes the summary line and closes the file.
+func (r *reportWriter) finish(lines int64, skipReasons, fieldRejections map[string]int64) error {
+	err := r.enc.Encode(reportSummary{
+		Type:            "summary",
+		Lines:           lines,
+		SkipReasons:     skipReasons,
+		FieldRejections: fieldRejections,
+	})
+	if err == nil {
+		err = r.w.Flush()
+	}
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
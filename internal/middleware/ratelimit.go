@@ -56,14 +56,35 @@ func (rl *rateLimiterStore) cleanup() {
 	}
 }
 
+// Limiter holds per-IP token buckets. One Limiter can back several servers
+// so a client shares its budget across them.
+type Limiter struct {
+	store *rateLimiterStore
+}
+
+// NewLimiter returns a Limiter allowing each IP rps requests per second with
+// a burst of burst requests.
+func NewLimiter(rps float64, burst int) *Limiter {
+	return &Limiter{store: newRateLimiterStore(rps, burst)}
+}
+
+// Allow reports whether a request from ip may proceed now.
+func (l *Limiter) Allow(ip string) bool {
+	return l.store.get(ip).Allow()
+}
+
 // RateLimit returns a middleware that limits each IP to rps requests per second
 // with a burst of burst requests.
 func RateLimit(rps float64, burst int) func(http.Handler) http.Handler {
-	store := newRateLimiterStore(rps, burst)
+	return RateLimitWith(NewLimiter(rps, burst))
+}
+
+// RateLimitWith is RateLimit drawing on an existing Limiter.
+func RateLimitWith(l *Limiter) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ip := realIP(r)
-			if !store.get(ip).Allow() {
+			if !l.Allow(ip) {
 				http.Error(w, "too many requests", http.StatusTooManyRequests)
 				return
 			}
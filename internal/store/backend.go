@@ -0,0 +1,52 @@
+package store
+
+import "fmt"
+
+// ProductLookup reads products by barcode. *Store implements it.
+type ProductLookup interface {
+	// Get returns (Product, false, nil) when the barcode is not found.
+	Get(barcode string) (Product, bool, error)
+	// ScanPrefix pages through products whose barcode starts with prefix.
+	ScanPrefix(prefix, cursor string, limit int) (products []Product, next string, err error)
+}
+
+// ProductSearcher answers free-text product searches. *Store implements it
+// with Bleve and *TrigramSearcher with an in-memory trigram index.
+type ProductSearcher interface {
+	// SearchWith returns up to opts.Limit products ranked by relevance.
+	// Callers must not modify the returned products.
+	SearchWith(q string, opts SearchOptions) ([]Product, error)
+}
+
+// NameSuggester completes and corrects search input. *Store implements it
+// from the suggest FSTs.
+type NameSuggester interface {
+	Suggest(q string, k int) ([]Suggestion, error)
+	DidYouMean(q string) (string, error)
+}
+
+// Search backends selectable with NewSearcher.
+const (
+	SearchBackendBleve   = "bleve"
+	SearchBackendTrigram = "trigram"
+)
+
+// NewSearcher returns the named search backend over s: s itself for
+// SearchBackendBleve, or a trigram index built from s's records for
+// SearchBackendTrigram.
+func NewSearcher(backend string, s *Store) (ProductSearcher, error) {
+	switch backend {
+	case SearchBackendBleve, "":
+		return s, nil
+	case SearchBackendTrigram:
+		return NewTrigramSearcher(s)
+	}
+	return nil, fmt.Errorf("unknown search backend %q (want %s or %s)", backend, SearchBackendBleve, SearchBackendTrigram)
+}
+
+var (
+	_ ProductLookup   = (*Store)(nil)
+	_ ProductSearcher = (*Store)(nil)
+	_ ProductSearcher = (*TrigramSearcher)(nil)
+	_ NameSuggester   = (*Store)(nil)
+)
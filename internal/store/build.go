@@ -0,0 +1,157 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/cockroachdb/pebble"
+)
+
+const (
+	// indexBuildDir is the scratch directory inside the data dir holding the
+	// sorted text-index documents and the builder's intermediate segments.
+	indexBuildDir = "index-tmp"
+
+	// indexFeedDepth is the number of documents queued per shard builder.
+	indexFeedDepth = 1024
+)
+
+// CreateOffline initialises a fresh data directory whose Bleve index is built
+// offline with bleve.NewBuilder instead of being written live. Documents
+// from the store's single WriteBatch are sorted on disk by barcode (the last
+// write or Unindex of a barcode wins) and written into n shards, each merged
+// into one optimised segment, when the batch is closed. Search is not
+// available on the returned store; reopen the directory to query it.
+func CreateOffline(dataDir string, n int) (*Store, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid shard count %d", n)
+	}
+	if err := os.MkdirAll(filepath.Join(dataDir, indexBuildDir), 0o755); err != nil {
+		return nil, fmt.Errorf("create index build dir: %w", err)
+	}
+	opts, cache := PebbleOptions{}.pebbleOptions(false)
+	db, err := pebble.Open(filepath.Join(dataDir, pebbleDir), opts)
+	cache.Unref()
+	if err != nil {
+		return nil, fmt.Errorf("create pebble: %w", err)
+	}
+	return &Store{dir: dataDir, db: db, opts: opts, offline: n, writable: true}, nil
+}
+
+// builtDoc is one document queued for a shard builder.
+type builtDoc struct {
+	id  string
+	doc bleveDoc
+}
+
+// buildIndex writes the sorted documents into one offline builder per shard.
+// Each builder analyses its documents on its own goroutine.
+func (s *Store) buildIndex(docs *kvSorter) error {
+	n := s.offline
+	mapping := newBleveMapping()
+	paths := make([]string, n)
+	builders := make([]bleve.Builder, n)
+	for i := range n {
+		paths[i] = filepath.Join(s.dir, bleveDir)
+		if n > 1 {
+			paths[i] = filepath.Join(paths[i], fmt.Sprintf(shardDirFormat, i))
+		}
+		b, err := bleve.NewBuilder(paths[i], mapping, map[string]interface{}{"buildPathPrefix": docs.dir})
+		if err != nil {
+			return fmt.Errorf("create bleve builder (shard %d): %w", i, err)
+		}
+		builders[i] = b
+	}
+
+	counts := make([]int64, n)
+	errs := make([]error, n)
+	feeds := make([]chan builtDoc, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		feeds[i] = make(chan builtDoc, indexFeedDepth)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range feeds[i] {
+				if errs[i] == nil {
+					errs[i] = builders[i].Index(d.id, d.doc)
+				}
+			}
+		}()
+	}
+
+	err := docs.merge(func(key, value []byte) error {
+		if len(value) == 0 {
+			return nil // unindexed
+		}
+		var doc bleveDoc
+		if err := json.Unmarshal(value, &doc); err != nil {
+			return fmt.Errorf("decode index document %q: %w", key, err)
+		}
+		i := s.shardFor(string(key))
+		counts[i]++
+		feeds[i] <- builtDoc{id: string(key), doc: doc}
+		return nil
+	})
+	for _, f := range feeds {
+		close(f)
+	}
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+
+	for i, b := range builders {
+		if errs[i] != nil {
+			return fmt.Errorf("bleve build (shard %d): %w", i, errs[i])
+		}
+		if counts[i] == 0 {
+			// The builder cannot finish an index without segments, so an
+			// empty shard gets a regular empty index instead.
+			if err := os.RemoveAll(paths[i]); err != nil {
+				return fmt.Errorf("remove empty bleve shard %d: %w", i, err)
+			}
+			idx, err := bleve.New(paths[i], mapping)
+			if err != nil {
+				return fmt.Errorf("create empty bleve shard %d: %w", i, err)
+			}
+			if err := idx.Close(); err != nil {
+				return fmt.Errorf("close empty bleve shard %d: %w", i, err)
+			}
+			continue
+		}
+		if err := b.Close(); err != nil {
+			return fmt.Errorf("finish bleve build (shard %d): %w", i, err)
+		}
+	}
+	return nil
+}
+
+// IndexFootprint returns the on-disk size in bytes of the Bleve index in
+// dataDir, over all shards, and its number of segment files.
+func IndexFootprint(dataDir string) (bytes int64, segments int, err error) {
+	err = filepath.WalkDir(filepath.Join(dataDir, bleveDir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		bytes += info.Size()
+		if strings.HasSuffix(path, ".zap") {
+			segments++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("measure bleve index: %w", err)
+	}
+	return bytes, segments, nil
+}
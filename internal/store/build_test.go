@@ -0,0 +1,90 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateOffline(t *testing.T) {
+	dir := t.TempDir()
+	s, err := CreateOffline(dir, 3)
+	if err != nil {
+		t.Fatalf("CreateOffline: %v", err)
+	}
+	if err := s.Put(Product{Barcode: "1", Name: "x"}); err == nil {
+		t.Error("Put on an offline store succeeded; want an error")
+	}
+
+	batch := s.NewWriteBatch()
+	batch.docs.runBytes = 2 << 10 // force several runs
+	for i := range 300 {
+		batch.Put(Product{Barcode: fmt.Sprintf("%013d", i), Name: "Rye Bread"})
+		if batch.Len() >= 50 {
+			if err := batch.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+		}
+	}
+	batch.Put(Product{Barcode: fmt.Sprintf("%013d", 7), Name: "Spelt Bread"}) // replaces Rye
+	batch.Unindex(fmt.Sprintf("%013d", 8))
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close batch: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, indexBuildDir)); !os.IsNotExist(err) {
+		t.Errorf("index build dir still present: %v", err)
+	}
+
+	size, segments, err := IndexFootprint(dir)
+	if err != nil {
+		t.Fatalf("IndexFootprint: %v", err)
+	}
+	if size == 0 || segments != 3 {
+		t.Errorf("IndexFootprint = %d bytes, %d segments; want one segment per shard", size, segments)
+	}
+
+	rs, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer rs.Close()
+	if rs.Shards() != 3 {
+		t.Errorf("Shards() = %d; want 3", rs.Shards())
+	}
+	if n, err := rs.index.DocCount(); err != nil || n != 299 {
+		t.Errorf("DocCount = %d, %v; want 299 (one unindexed)", n, err)
+	}
+	results, err := rs.Search("spelt", 10)
+	if err != nil || len(results) != 1 || results[0].Barcode != fmt.Sprintf("%013d", 7) {
+		t.Errorf("Search(spelt) = %v, %v; want the replaced product", results, err)
+	}
+}
+
+func TestCreateOffline_EmptyShard(t *testing.T) {
+	dir := t.TempDir()
+	s, err := CreateOffline(dir, 4)
+	if err != nil {
+		t.Fatalf("CreateOffline: %v", err)
+	}
+	batch := s.NewWriteBatch()
+	batch.Put(Product{Barcode: "4006381333931", Name: "Sparkling Water"})
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close batch: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rs, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer rs.Close()
+	if results, err := rs.Search("water", 10); err != nil || len(results) != 1 {
+		t.Errorf("Search(water) = %v, %v; want 1 result", results, err)
+	}
+}
@@ -0,0 +1,199 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// productOverhead approximates the fixed in-memory size of a cached Product
+// plus its list and map bookkeeping; string and slice contents are added on
+// top.
+const productOverhead = int64(unsafe.Sizeof(Product{})) + 64
+
+// CacheStats is a point-in-time view of the result cache.
+type CacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"`
+	MaxBytes  int64 `json:"max_bytes"`
+}
+
+// resultCache is a size-bounded LRU of Get and Search results. Every key is
+// scoped to a generation (data dir and manifest build time); changing the
+// generation drops all entries.
+type resultCache struct {
+	maxBytes int64
+
+	mu         sync.Mutex
+	generation string
+	bytes      int64
+	ll         *list.List // front = most recently used
+	items      map[string]*list.Element
+
+	hits, misses, evictions atomic.Int64
+}
+
+type cacheEntry struct {
+	key      string
+	products []Product // one element for Get hits, nil for Get misses
+	size     int64
+}
+
+func newResultCache(maxBytes int64, generation string) *resultCache {
+	return &resultCache{
+		maxBytes:   maxBytes,
+		generation: generation,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached products for key. Callers must not modify them.
+func (c *resultCache) get(key string) ([]Product, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).products, true
+}
+
+// put stores products under key, evicting least recently used entries until
+// the cache fits in maxBytes. Results larger than the whole cache are skipped.
+func (c *resultCache) put(key string, products []Product) {
+	size := int64(len(key)) + productsSize(products)
+	if size > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, products: products, size: size})
+	c.bytes += size
+	for c.bytes > c.maxBytes {
+		c.removeElement(c.ll.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *resultCache) removeElement(el *list.Element) {
+	e := c.ll.Remove(el).(*cacheEntry)
+	delete(c.items, e.key)
+	c.bytes -= e.size
+}
+
+// setGeneration drops every entry when generation differs from the current
+// one and reports whether it did.
+func (c *resultCache) setGeneration(generation string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation == c.generation {
+		return false
+	}
+	c.generation = generation
+	c.ll.Init()
+	clear(c.items)
+	c.bytes = 0
+	return true
+}
+
+func (c *resultCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Entries:   len(c.items),
+		Bytes:     c.bytes,
+		MaxBytes:  c.maxBytes,
+	}
+}
+
+// productsSize estimates the memory held by a cached result.
+func productsSize(ps []Product) int64 {
+	n := int64(0)
+	for _, p := range ps {
+		n += productOverhead + int64(len(p.Barcode)+len(p.Name)+len(p.Brand)+len(p.Lang))
+		for _, a := range p.Alternates {
+			n += 16 + int64(len(a))
+		}
+	}
+	return n
+}
+
+// getCacheKey and searchKey build cache keys; the leading byte keeps the
+// two namespaces apart. searchKey also identifies a search for coalescing.
+func getCacheKey(barcode string) string {
+	return "g\x00" + barcode
+}
+
+func searchKey(folded string, limit int, opts SearchOptions, synonymsVersion uint64) string {
+	return fmt.Sprintf("s\x00%s\x00%d\x00%s\x00%t\x00%s\x00%t\x00%d",
+		folded, limit, strings.ToLower(opts.Lang), opts.Collapse,
+		strings.ToUpper(opts.GS1Country), opts.ExcludeLowQuality, synonymsVersion)
+}
+
+// cacheGeneration identifies the dataset in dir: its path plus the manifest
+// build time, when there is a manifest.
+func cacheGeneration(dir string) string {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return dir
+	}
+	return dir + "@" + m.BuildTime.UTC().Format(time.RFC3339Nano)
+}
+
+// EnableCache puts a size-bounded LRU cache of maxBytes in front of Get and
+// SearchWith. It must be called before the store starts serving requests.
+func (s *Store) EnableCache(maxBytes int64) {
+	if maxBytes <= 0 {
+		return
+	}
+	s.cache = newResultCache(maxBytes, cacheGeneration(s.dir))
+}
+
+// CacheStats returns the cache counters, and false when caching is disabled.
+func (s *Store) CacheStats() (CacheStats, bool) {
+	if s.cache == nil {
+		return CacheStats{}, false
+	}
+	return s.cache.stats(), true
+}
+
+// WatchCacheGeneration re-reads the manifest every interval until ctx is
+// cancelled and drops all cached results when the dataset's build time
+// changes.
+func (s *Store) WatchCacheGeneration(ctx context.Context, interval time.Duration) {
+	if s.cache == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			gen := cacheGeneration(s.dir)
+			if s.cache.setGeneration(gen) {
+				slog.Info("result cache invalidated", "generation", gen)
+			}
+		}
+	}
+}
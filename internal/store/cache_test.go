@@ -0,0 +1,105 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestResultCache_LRU(t *testing.T) {
+	one := []Product{{Barcode: "1", Name: "Milk"}}
+	size := int64(len(getCacheKey("1"))) + productsSize(one)
+	c := newResultCache(3*size, "gen")
+
+	for i := range 3 {
+		c.put(getCacheKey(fmt.Sprint(i)), []Product{{Barcode: "1", Name: "Milk"}})
+	}
+	if _, ok := c.get(getCacheKey("0")); !ok { // 0 is now most recently used
+		t.Fatal("entry 0 missing before eviction")
+	}
+	c.put(getCacheKey("3"), one)
+
+	if _, ok := c.get(getCacheKey("1")); ok {
+		t.Error("least recently used entry 1 was not evicted")
+	}
+	for _, k := range []string{"0", "2", "3"} {
+		if _, ok := c.get(getCacheKey(k)); !ok {
+			t.Errorf("entry %s evicted; want kept", k)
+		}
+	}
+
+	st := c.stats()
+	if st.Entries != 3 || st.Bytes != 3*size || st.Evictions != 1 || st.Hits != 4 || st.Misses != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+
+	// A result bigger than the whole cache is not stored.
+	big := make([]Product, 10)
+	c.put("big", big)
+	if _, ok := c.get("big"); ok {
+		t.Error("oversized result was cached")
+	}
+
+	if c.setGeneration("gen") {
+		t.Error("setGeneration with same generation reported a change")
+	}
+	if !c.setGeneration("gen2") || c.stats().Entries != 0 || c.stats().Bytes != 0 {
+		t.Errorf("setGeneration did not purge: %+v", c.stats())
+	}
+}
+
+func TestStore_Cache(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Create(dir)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer s.Close()
+	if err := WriteManifest(dir, &Manifest{BuildTime: time.Unix(1, 0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	batch := s.NewWriteBatch()
+	batch.Put(Product{Barcode: "001", Name: "Chicken Breast"})
+	batch.Put(Product{Barcode: "002", Name: "Chicken Nuggets"})
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, ok := s.CacheStats(); ok {
+		t.Fatal("CacheStats reported enabled before EnableCache")
+	}
+	s.EnableCache(1 << 20)
+
+	for range 2 {
+		if _, ok, err := s.Get("001"); err != nil || !ok {
+			t.Fatalf("Get(001) = %v, %v", ok, err)
+		}
+		if _, ok, err := s.Get("999"); err != nil || ok {
+			t.Fatalf("Get(999) = %v, %v; want not found", ok, err)
+		}
+		if ps, err := s.SearchWith("Chicken", SearchOptions{Limit: 10}); err != nil || len(ps) != 2 {
+			t.Fatalf("SearchWith = %d results, %v", len(ps), err)
+		}
+	}
+	// Same folded query, different filters: a separate entry.
+	if _, err := s.SearchWith("chicken", SearchOptions{Limit: 10, ExcludeLowQuality: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	st, _ := s.CacheStats()
+	if st.Hits != 3 || st.Misses != 4 || st.Entries != 4 {
+		t.Errorf("stats = %+v; want 3 hits, 4 misses, 4 entries", st)
+	}
+
+	// A rebuilt dataset (new manifest build time) invalidates everything.
+	if err := WriteManifest(dir, &Manifest{BuildTime: time.Unix(2, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	if !s.cache.setGeneration(cacheGeneration(dir)) {
+		t.Error("new build time did not change the cache generation")
+	}
+	if st, _ := s.CacheStats(); st.Entries != 0 {
+		t.Errorf("entries after invalidation = %d; want 0", st.Entries)
+	}
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// searchFlight shares one execution between identical concurrent searches.
+type searchFlight struct {
+	group     singleflight.Group
+	coalesced atomic.Int64 // callers that reused another caller's execution
+}
+
+// do runs fn once per key among concurrent callers; the others wait for and
+// share its result.
+func (f *searchFlight) do(key string, fn func() ([]Product, error)) ([]Product, error) {
+	executed := false
+	v, err, _ := f.group.Do(key, func() (any, error) {
+		executed = true
+		return fn()
+	})
+	if !executed {
+		f.coalesced.Add(1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Product), nil
+}
+
+// CoalescedSearches returns how many searches were answered by sharing a
+// concurrent identical search instead of running their own.
+func (s *Store) CoalescedSearches() int64 {
+	return s.flight.coalesced.Load()
+}
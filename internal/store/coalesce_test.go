@@ -0,0 +1,55 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSearchFlight_Coalesces(t *testing.T) {
+	var f searchFlight
+	release := make(chan struct{})
+	var calls int
+	fn := func() ([]Product, error) {
+		calls++
+		<-release
+		return []Product{{Barcode: "1"}}, nil
+	}
+
+	const n = 8
+	var wg sync.WaitGroup
+	results := make([][]Product, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = f.do("milk", fn)
+		}()
+	}
+	// Give every caller time to join the in-flight execution.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("fn ran %d times; want 1", calls)
+	}
+	if got := f.coalesced.Load(); got != n-1 {
+		t.Errorf("coalesced = %d; want %d", got, n-1)
+	}
+	for i, r := range results {
+		if len(r) != 1 || r[0].Barcode != "1" {
+			t.Errorf("caller %d got %v", i, r)
+		}
+	}
+
+	// Sequential calls are not coalesced, and errors reach the caller.
+	boom := errors.New("boom")
+	if _, err := f.do("milk", func() ([]Product, error) { return nil, boom }); err != boom {
+		t.Errorf("err = %v; want %v", err, boom)
+	}
+	if got := f.coalesced.Load(); got != n-1 {
+		t.Errorf("coalesced after sequential call = %d; want %d", got, n-1)
+	}
+}
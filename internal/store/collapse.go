@@ -0,0 +1,57 @@
+package store
+
+import "math"
+
+// Tolerances for treating two products' macros as near-identical: the larger
+// of the absolute slack and the relative slack of the bigger value.
+const (
+	collapseKcalAbs  = 5   // kcal per 100g
+	collapseMacroAbs = 0.5 // g per 100g
+	collapseRel      = 0.05
+)
+
+// collapseProducts groups ranked products that share folded name and brand
+// and have near-identical macros. Each group is represented by its best
+// ranked member, with the other members' barcodes in Alternates. Rank order
+// of the representatives is preserved.
+func collapseProducts(ranked []Product) []Product {
+	type groupKey struct{ name, brand string }
+	groups := make(map[groupKey][]int) // key → indexes into out
+	out := make([]Product, 0, len(ranked))
+
+	for _, p := range ranked {
+		key := groupKey{FoldName(p.Name), FoldName(p.Brand)}
+		merged := false
+		for _, i := range groups[key] {
+			if macrosClose(out[i], p) {
+				out[i].Alternates = append(out[i].Alternates, p.Barcode)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			p.Alternates = nil
+			groups[key] = append(groups[key], len(out))
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// macrosClose reports whether a and b have near-identical nutrients. A
+// nutrient missing on both sides matches; missing on one side does not.
+func macrosClose(a, b Product) bool {
+	return nutrientClose(a.Kcal100g, b.Kcal100g, collapseKcalAbs) &&
+		nutrientClose(a.Protein, b.Protein, collapseMacroAbs) &&
+		nutrientClose(a.Fat, b.Fat, collapseMacroAbs) &&
+		nutrientClose(a.Carbs, b.Carbs, collapseMacroAbs)
+}
+
+func nutrientClose(a, b float32, abs float64) bool {
+	x, y := float64(a), float64(b)
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return math.IsNaN(x) && math.IsNaN(y)
+	}
+	slack := math.Max(abs, collapseRel*math.Max(math.Abs(x), math.Abs(y)))
+	return math.Abs(x-y) <= slack
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCollapseProducts(t *testing.T) {
+	nan := float32(math.NaN())
+	ranked := []Product{
+		{Barcode: "1", Name: "Nutella", Brand: "Ferrero", Kcal100g: 539, Protein: 6.3, Fat: 30.9, Carbs: 57.5},
+		{Barcode: "2", Name: "Apple Juice", Brand: "Valensina", Kcal100g: 45, Protein: nan, Fat: nan, Carbs: 10},
+		{Barcode: "3", Name: "NUTELLA", Brand: "ferrero", Kcal100g: 544, Protein: 6.2, Fat: 31, Carbs: 57},     // same, within tolerance
+		{Barcode: "4", Name: "Nutella", Brand: "Ferrero", Kcal100g: 400, Protein: 6.3, Fat: 30.9, Carbs: 57.5}, // kcal too far
+		{Barcode: "5", Name: "Nutella", Brand: "Other", Kcal100g: 539, Protein: 6.3, Fat: 30.9, Carbs: 57.5},   // other brand
+		{Barcode: "6", Name: "Apple Juice", Brand: "Valensina", Kcal100g: 46, Protein: nan, Fat: nan, Carbs: 10.2},
+		{Barcode: "7", Name: "Apple Juice", Brand: "Valensina", Kcal100g: 46, Protein: 0.1, Fat: nan, Carbs: 10.2}, // protein missing vs present
+	}
+
+	got := collapseProducts(ranked)
+
+	var barcodes []string
+	alternates := map[string][]string{}
+	for _, p := range got {
+		barcodes = append(barcodes, p.Barcode)
+		alternates[p.Barcode] = p.Alternates
+	}
+	if want := []string{"1", "2", "4", "5", "7"}; !reflect.DeepEqual(barcodes, want) {
+		t.Errorf("representatives = %v; want %v", barcodes, want)
+	}
+	if want := []string{"3"}; !reflect.DeepEqual(alternates["1"], want) {
+		t.Errorf("alternates of 1 = %v; want %v", alternates["1"], want)
+	}
+	if want := []string{"6"}; !reflect.DeepEqual(alternates["2"], want) {
+		t.Errorf("alternates of 2 = %v; want %v", alternates["2"], want)
+	}
+	if alternates["4"] != nil || alternates["5"] != nil {
+		t.Errorf("unexpected alternates: 4=%v 5=%v", alternates["4"], alternates["5"])
+	}
+}
+
+func TestSearch_CollapseKeepsPageSize(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Create(dir)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer s.Close()
+
+	batch := s.NewWriteBatch()
+	for i := 0; i < 30; i++ {
+		batch.Put(Product{Barcode: fmt.Sprintf("%03d", i), Name: "Nutella", Brand: "Ferrero", Kcal100g: 539})
+	}
+	batch.Put(Product{Barcode: "x1", Name: "Nutella Biscuits", Brand: "Ferrero", Kcal100g: 511})
+	batch.Put(Product{Barcode: "x2", Name: "Nutella B-ready", Brand: "Ferrero", Kcal100g: 536})
+	if err := batch.Close(); err != nil {
+		t.Fatalf("batch close: %v", err)
+	}
+
+	res, err := s.SearchWith("nutella", SearchOptions{Limit: 3, Collapse: true})
+	if err != nil {
+		t.Fatalf("SearchWith: %v", err)
+	}
+	if len(res) != 3 {
+		t.Fatalf("got %d collapsed results; want 3", len(res))
+	}
+	if len(res[0].Alternates) != 29 {
+		t.Errorf("top result has %d alternates; want 29", len(res[0].Alternates))
+	}
+}
@@ -0,0 +1,354 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/cockroachdb/pebble/objstorage/objstorageprovider"
+	"github.com/cockroachdb/pebble/sstable"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+const (
+	// ingestDir is the scratch directory inside the data dir holding sorted
+	// runs and the SSTables built from them. It is removed once ingested.
+	ingestDir = "ingest-tmp"
+
+	// ingestRunBytes is the memory budget of the sorter; a full buffer is
+	// sorted and spilled to disk as one run.
+	ingestRunBytes = 64 << 20
+
+	// ingestTableBytes is the target size of each SSTable written for
+	// ingestion.
+	ingestTableBytes = 128 << 20
+
+	// kvOverhead approximates the buffer bookkeeping per sorted record.
+	kvOverhead = 48
+)
+
+// sortedKV is one record of the external sort. seq orders repeated writes of
+// the same key so the latest one wins.
+type sortedKV struct {
+	key, value []byte
+	seq        uint64
+}
+
+func compareKV(a, b sortedKV) int {
+	if c := bytes.Compare(a.key, b.key); c != 0 {
+		return c
+	}
+	switch {
+	case a.seq < b.seq:
+		return -1
+	case a.seq > b.seq:
+		return 1
+	}
+	return 0
+}
+
+// kvSorter is an external merge sort over key/value pairs. Records are
+// buffered in memory, spilled to dir as sorted runs whenever the buffer
+// reaches runBytes, and merged back in key order by merge.
+type kvSorter struct {
+	dir      string
+	runBytes int
+	buf      []sortedKV
+	bufBytes int
+	seq      uint64
+	runs     []string
+}
+
+func newKVSorter(dir string, runBytes int) *kvSorter {
+	return &kvSorter{dir: dir, runBytes: runBytes}
+}
+
+// add buffers a copy of key and value.
+func (s *kvSorter) add(key, value []byte) error {
+	s.seq++
+	s.buf = append(s.buf, sortedKV{key: bytes.Clone(key), value: bytes.Clone(value), seq: s.seq})
+	s.bufBytes += len(key) + len(value) + kvOverhead
+	if s.bufBytes >= s.runBytes {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill writes the sorted buffer to a new run file. A run is a sequence of
+// uvarint(len key) key uvarint(seq) uvarint(len value) value.
+func (s *kvSorter) spill() error {
+	slices.SortFunc(s.buf, compareKV)
+	path := filepath.Join(s.dir, fmt.Sprintf("run-%06d", len(s.runs)))
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create sort run: %w", err)
+	}
+	w := bufio.NewWriterSize(f, 1<<20)
+	var hdr [binary.MaxVarintLen64]byte
+	for _, kv := range s.buf {
+		w.Write(hdr[:binary.PutUvarint(hdr[:], uint64(len(kv.key)))])
+		w.Write(kv.key)
+		w.Write(hdr[:binary.PutUvarint(hdr[:], kv.seq)])
+		w.Write(hdr[:binary.PutUvarint(hdr[:], uint64(len(kv.value)))])
+		w.Write(kv.value)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write sort run: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close sort run: %w", err)
+	}
+	s.runs = append(s.runs, path)
+	s.buf = s.buf[:0]
+	s.bufBytes = 0
+	return nil
+}
+
+// merge calls emit once per distinct key in ascending key order with the
+// value added last for that key. The slices passed to emit are only valid
+// for the duration of the call.
+func (s *kvSorter) merge(emit func(key, value []byte) error) error {
+	slices.SortFunc(s.buf, compareKV)
+	h := make(cursorHeap, 0, len(s.runs)+1)
+	defer func() {
+		for _, c := range h {
+			c.close()
+		}
+	}()
+	for _, path := range s.runs {
+		c, err := openRunCursor(path)
+		if err != nil {
+			return err
+		}
+		if err := c.next(); err != nil {
+			c.close()
+			if err == io.EOF {
+				continue
+			}
+			return err
+		}
+		h = append(h, c)
+	}
+	if mem := (&memCursor{kvs: s.buf}); mem.next() == nil {
+		h = append(h, mem)
+	}
+	heap.Init(&h)
+
+	var pending sortedKV
+	havePending := false
+	for len(h) > 0 {
+		c := h[0]
+		kv := c.current()
+		if havePending && !bytes.Equal(kv.key, pending.key) {
+			if err := emit(pending.key, pending.value); err != nil {
+				return err
+			}
+			havePending = false
+		}
+		// Equal keys arrive in ascending seq order, so the last one seen is
+		// the latest write. Copy it: run cursors reuse their buffers.
+		pending = sortedKV{key: append(pending.key[:0], kv.key...), value: append(pending.value[:0], kv.value...)}
+		havePending = true
+
+		switch err := c.next(); err {
+		case nil:
+			heap.Fix(&h, 0)
+		case io.EOF:
+			heap.Pop(&h).(kvCursor).close()
+		default:
+			return err
+		}
+	}
+	if havePending {
+		return emit(pending.key, pending.value)
+	}
+	return nil
+}
+
+// cleanup removes every spilled run.
+func (s *kvSorter) cleanup() {
+	for _, path := range s.runs {
+		_ = os.Remove(path)
+	}
+	s.runs = nil
+	s.buf = nil
+}
+
+// kvCursor iterates one sorted source of the merge.
+type kvCursor interface {
+	current() sortedKV
+	next() error // io.EOF when exhausted
+	close()
+}
+
+type cursorHeap []kvCursor
+
+func (h cursorHeap) Len() int           { return len(h) }
+func (h cursorHeap) Less(i, j int) bool { return compareKV(h[i].current(), h[j].current()) < 0 }
+func (h cursorHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *cursorHeap) Push(x any)        { *h = append(*h, x.(kvCursor)) }
+func (h *cursorHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+type memCursor struct {
+	kvs []sortedKV
+	pos int
+}
+
+func (c *memCursor) current() sortedKV { return c.kvs[c.pos-1] }
+func (c *memCursor) close()            {}
+
+func (c *memCursor) next() error {
+	if c.pos >= len(c.kvs) {
+		return io.EOF
+	}
+	c.pos++
+	return nil
+}
+
+type runCursor struct {
+	f   *os.File
+	r   *bufio.Reader
+	cur sortedKV
+}
+
+func openRunCursor(path string) (*runCursor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open sort run: %w", err)
+	}
+	return &runCursor{f: f, r: bufio.NewReaderSize(f, 1<<20)}, nil
+}
+
+func (c *runCursor) current() sortedKV { return c.cur }
+func (c *runCursor) close()            { _ = c.f.Close() }
+
+func (c *runCursor) next() error {
+	klen, err := binary.ReadUvarint(c.r)
+	if err != nil {
+		return err // io.EOF at a record boundary
+	}
+	if c.cur.key, err = readN(c.r, c.cur.key, klen); err != nil {
+		return err
+	}
+	if c.cur.seq, err = binary.ReadUvarint(c.r); err != nil {
+		return runErr(err)
+	}
+	vlen, err := binary.ReadUvarint(c.r)
+	if err != nil {
+		return runErr(err)
+	}
+	c.cur.value, err = readN(c.r, c.cur.value, vlen)
+	return err
+}
+
+func readN(r io.Reader, buf []byte, n uint64) ([]byte, error) {
+	buf = slices.Grow(buf[:0], int(n))[:n]
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, runErr(err)
+	}
+	return buf, nil
+}
+
+// runErr reports a run that ends mid-record as corrupt rather than exhausted.
+func runErr(err error) error {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("read sort run: %w", err)
+}
+
+// ingestSorted writes the sorter's records into SSTables for the bottom
+// level, ingests them into the (empty) Pebble DB and compacts the whole key
+// range so the result is a single fully compacted LSM.
+func (s *Store) ingestSorted(sorter *kvSorter) error {
+	wopts := s.opts.MakeWriterOptions(pebbleLevels-1, s.db.FormatMajorVersion().MaxTableFormat())
+
+	var (
+		paths       []string
+		w           *sstable.Writer
+		first, last []byte
+	)
+	finishTable := func() error {
+		if w == nil {
+			return nil
+		}
+		err := w.Close()
+		w = nil
+		if err != nil {
+			return fmt.Errorf("close sstable: %w", err)
+		}
+		return nil
+	}
+	emit := func(key, value []byte) error {
+		if w == nil {
+			path := filepath.Join(sorter.dir, fmt.Sprintf("table-%06d.sst", len(paths)))
+			f, err := vfs.Default.Create(path)
+			if err != nil {
+				return fmt.Errorf("create sstable: %w", err)
+			}
+			w = sstable.NewWriter(objstorageprovider.NewFileWritable(f), wopts)
+			paths = append(paths, path)
+		}
+		if err := w.Set(key, value); err != nil {
+			return fmt.Errorf("sstable set: %w", err)
+		}
+		if first == nil {
+			first = bytes.Clone(key)
+		}
+		last = append(last[:0], key...)
+		if w.EstimatedSize() >= ingestTableBytes {
+			return finishTable()
+		}
+		return nil
+	}
+
+	err := sorter.merge(emit)
+	if cerr := finishTable(); err == nil {
+		err = cerr
+	}
+	sorter.cleanup()
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	if err := s.db.Ingest(paths); err != nil {
+		return fmt.Errorf("pebble ingest: %w", err)
+	}
+	if err := s.db.Compact(first, append(last, 0), true); err != nil {
+		return fmt.Errorf("pebble compact: %w", err)
+	}
+	return nil
+}
+
+// NewIngestBatch is NewWriteBatch for a freshly created store whose Pebble
+// records are written by sorted SSTable ingestion instead of batch commits.
+// Put sends records to an external merge sort in a scratch directory; Flush
+// only commits the text index; Close sorts, writes SSTables, ingests them and
+// fully compacts the DB. It is meant for a single batch per store.
+func (s *Store) NewIngestBatch() (*WriteBatch, error) {
+	dir := filepath.Join(s.dir, ingestDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create ingest dir: %w", err)
+	}
+	b := s.NewWriteBatch()
+	b.pb.Close()
+	b.pb = nil
+	b.sorter = newKVSorter(dir, ingestRunBytes)
+	return b, nil
+}
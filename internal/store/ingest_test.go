@@ -0,0 +1,112 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKVSorter_MergesRunsLatestWins(t *testing.T) {
+	// A tiny budget spills a run every few records, so keys and their
+	// rewrites end up spread across runs and the in-memory tail.
+	s := newKVSorter(t.TempDir(), 200)
+	for i := range 100 {
+		key := fmt.Sprintf("%03d", (i*37)%50)
+		if err := s.add([]byte(key), []byte(fmt.Sprintf("v%d", i))); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	if len(s.runs) < 2 {
+		t.Fatalf("runs = %d; want the buffer to spill several times", len(s.runs))
+	}
+
+	var keys []string
+	values := map[string]string{}
+	err := s.merge(func(k, v []byte) error {
+		keys = append(keys, string(k))
+		values[string(k)] = string(v)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if len(keys) != 50 {
+		t.Fatalf("merge emitted %d keys; want 50", len(keys))
+	}
+	for i, k := range keys {
+		if k != fmt.Sprintf("%03d", i) {
+			t.Fatalf("keys[%d] = %q; want ascending order", i, k)
+		}
+	}
+	// Key (i*37)%50 is written at i and i+50; the second write wins.
+	for i := 50; i < 100; i++ {
+		key := fmt.Sprintf("%03d", (i*37)%50)
+		if want := fmt.Sprintf("v%d", i); values[key] != want {
+			t.Errorf("value[%s] = %q; want %q", key, values[key], want)
+		}
+	}
+
+	s.cleanup()
+	if entries, _ := os.ReadDir(s.dir); len(entries) != 0 {
+		t.Errorf("cleanup left %d files", len(entries))
+	}
+}
+
+func TestIngestBatch(t *testing.T) {
+	dir := t.TempDir()
+	s, err := CreateSharded(dir, 2)
+	if err != nil {
+		t.Fatalf("CreateSharded: %v", err)
+	}
+	batch, err := s.NewIngestBatch()
+	if err != nil {
+		t.Fatalf("NewIngestBatch: %v", err)
+	}
+	batch.sorter.runBytes = 4 << 10 // force several runs
+	for i := range 500 {
+		batch.Put(Product{Barcode: fmt.Sprintf("%013d", 499-i), Name: "Oat Milk", Kcal100g: 40})
+		if batch.Len() >= 100 {
+			if err := batch.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+		}
+	}
+	batch.Put(Product{Barcode: "0000000000007", Name: "Soy Milk", Kcal100g: 33})
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close batch: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ingestDir)); !os.IsNotExist(err) {
+		t.Errorf("ingest dir still present: %v", err)
+	}
+
+	rs, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer rs.Close()
+
+	if p, ok, err := rs.Get("0000000000007"); err != nil || !ok || p.Name != "Soy Milk" {
+		t.Errorf("Get(7) = %q, %v, %v; want the later Soy Milk record", p.Name, ok, err)
+	}
+	if _, ok, _ := rs.Get("0000000000499"); !ok {
+		t.Error("Get(499): not found")
+	}
+	if results, _ := rs.Search("soy", 10); len(results) != 1 {
+		t.Errorf("Search(soy) returned %d results; want 1", len(results))
+	}
+
+	// Everything lands in the bottom level; nothing is left to compact.
+	m := rs.db.Metrics()
+	for level := 0; level < pebbleLevels-1; level++ {
+		if n := m.Levels[level].NumFiles; n != 0 {
+			t.Errorf("L%d has %d files; want 0", level, n)
+		}
+	}
+	if m.Levels[pebbleLevels-1].NumFiles == 0 {
+		t.Error("bottom level is empty")
+	}
+}
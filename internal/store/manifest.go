@@ -11,13 +11,21 @@ const manifestFile = "manifest.json"
 
 // Manifest records metadata about a built data directory.
 type Manifest struct {
-	BuildTime     time.Time       `json:"build_time"`
-	DumpSource    string          `json:"dump_source"`
-	ProductCount  int64           `json:"product_count"`
-	IndexedCount  int64           `json:"indexed_count"`
-	SkippedCount  int64           `json:"skipped_count"`
-	SchemaVersion int             `json:"schema_version"`
-	SkipReasons   map[string]int64 `json:"skip_reasons,omitempty"`
+	BuildTime       time.Time        `json:"build_time"`
+	DumpSource      string           `json:"dump_source"`
+	Filter          string           `json:"filter,omitempty"`
+	ProductCount    int64            `json:"product_count"`
+	IndexedCount    int64            `json:"indexed_count"`
+	SkippedCount    int64            `json:"skipped_count"`
+	SchemaVersion   int              `json:"schema_version"`
+	IndexShards     int              `json:"index_shards,omitempty"`
+	IndexBytes      int64            `json:"index_bytes,omitempty"`
+	IndexSegments   int              `json:"index_segments,omitempty"`
+	SkipReasons     map[string]int64 `json:"skip_reasons,omitempty"`
+	FieldRejections map[string]int64 `json:"field_rejections,omitempty"`
+	SuggestCount    int64            `json:"suggest_count,omitempty"`
+	BarcodeIndex    bool             `json:"barcode_index,omitempty"`
+	QualityFlags    map[string]int64 `json:"quality_flags,omitempty"`
 }
 
 // ReadManifest loads the manifest.json from the given data directory.
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/bloom"
+)
+
+const (
+	// DefaultBlockCacheSize is the Pebble block cache used when
+	// PebbleOptions.BlockCacheSize is zero.
+	DefaultBlockCacheSize = 64 << 20
+
+	// DefaultMaxOpenFiles bounds Pebble's table cache (open SSTables) when
+	// PebbleOptions.MaxOpenFiles is zero.
+	DefaultMaxOpenFiles = 1_000
+
+	// bloomBitsPerKey sizes the per-table bloom filter: 10 bits give ~1% false
+	// positives, so most barcode misses skip the SSTable's index and data
+	// blocks entirely.
+	bloomBitsPerKey = 10
+
+	// tableBlockSize is the target data block size. Records are ~100 bytes
+	// and reads are point lookups, so blocks stay small to keep the bytes
+	// read per Get low while still compressing well.
+	tableBlockSize = 8 << 10
+
+	// pebbleLevels is the number of LSM levels Pebble uses.
+	pebbleLevels = 7
+)
+
+// PebbleOptions tunes the Pebble instance opened by OpenReadOnlyWith.
+// Zero fields use the defaults above.
+type PebbleOptions struct {
+	BlockCacheSize int64 // bytes of decompressed blocks cached in memory
+	MaxOpenFiles   int   // SSTables kept open in the table cache
+}
+
+// levelOptions returns the per-level table options. The importer writes
+// tables with them and readers need the same filter policy to use the
+// filters, so both sides share this function.
+func levelOptions() []pebble.LevelOptions {
+	levels := make([]pebble.LevelOptions, pebbleLevels)
+	for i := range levels {
+		levels[i] = pebble.LevelOptions{
+			BlockSize:    tableBlockSize,
+			FilterPolicy: bloom.FilterPolicy(bloomBitsPerKey),
+			FilterType:   pebble.TableFilter,
+		}
+	}
+	return levels
+}
+
+// pebbleOptions builds the options for opening the KV store. The returned
+// cache must be released with Unref once the DB is open (or failed to open).
+func (po PebbleOptions) pebbleOptions(readOnly bool) (*pebble.Options, *pebble.Cache) {
+	size := po.BlockCacheSize
+	if size <= 0 {
+		size = DefaultBlockCacheSize
+	}
+	maxOpen := po.MaxOpenFiles
+	if maxOpen <= 0 {
+		maxOpen = DefaultMaxOpenFiles
+	}
+	cache := pebble.NewCache(size)
+	return &pebble.Options{
+		ReadOnly:     readOnly,
+		Cache:        cache,
+		MaxOpenFiles: maxOpen,
+		Levels:       levelOptions(),
+	}, cache
+}
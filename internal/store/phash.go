@@ -0,0 +1,324 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/blevesearch/mmap-go"
+)
+
+const (
+	phashDir         = "phash"
+	phashIndexFile   = "index.bin"
+	phashRecordsFile = "records.bin"
+
+	// phashMagic starts index.bin and versions its layout:
+	//
+	//	magic [8]byte | n uint64 | m uint64 | seeds [m]uint32 | offsets [n]uint64
+	//
+	// all little endian. records.bin is a sequence of
+	// uvarint(len barcode) barcode uvarint(len value) value, and offsets[slot]
+	// is the position of the record hashing to slot, or phashEmpty. Version
+	// 01 had exactly one slot per record and no empty slots, so it still
+	// reads as version 02.
+	phashMagic     = "FFDBPH02"
+	phashMagicV1   = "FFDBPH01"
+	phashHeaderLen = 24
+
+	// phashEmpty marks a slot no record hashes to.
+	phashEmpty = ^uint64(0)
+
+	// phashLoadFactor is the share of slots holding a record. With a
+	// twentieth of the table free to the end, the last buckets still find a
+	// seed within a few dozen tries; a full table needs about n tries each.
+	phashLoadFactor = 0.95
+
+	// phashBucketSize is the average number of keys per displacement bucket.
+	// Larger buckets shrink the seed table but make seeds harder to find.
+	phashBucketSize = 4
+
+	// phashMaxSeed bounds the displacement search for a single bucket.
+	phashMaxSeed = 1 << 24
+)
+
+// phashKey hashes a barcode once; bucket and slot are derived from it.
+func phashKey(barcode []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(barcode)
+	return mix64(h.Sum64())
+}
+
+// mix64 is the splitmix64 finaliser.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// phashSlot places a key hash in [0, n) for a bucket's displacement seed.
+func phashSlot(h uint64, seed uint32, n uint64) uint64 {
+	return mix64(h^(uint64(seed)*0x9e3779b97f4a7c15)) % n
+}
+
+// phashTableSize is the slot count for keys keys at phashLoadFactor.
+func phashTableSize(keys int) uint64 {
+	return uint64(float64(keys)/phashLoadFactor) + 1
+}
+
+// buildPerfectHash computes a perfect hash over hashes into
+// phashTableSize(len(hashes)) slots with the hash-and-displace method: keys
+// are grouped into buckets, and buckets, largest first, each search for the
+// first seed that maps all their keys to free slots. It returns the
+// per-bucket seeds and the slot of every key.
+func buildPerfectHash(hashes []uint64) (seeds []uint32, slots []uint64, err error) {
+	n := phashTableSize(len(hashes))
+	m := max(uint64(len(hashes))/phashBucketSize, 1)
+	buckets := make([][]int, m)
+	for i, h := range hashes {
+		b := h % m
+		buckets[b] = append(buckets[b], i)
+	}
+	order := make([]int, m)
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return len(buckets[b]) - len(buckets[a]) })
+
+	seeds = make([]uint32, m)
+	slots = make([]uint64, len(hashes))
+	taken := make([]bool, n)
+	var tried []uint64
+	for _, b := range order {
+		keys := buckets[b]
+		if len(keys) == 0 {
+			break
+		}
+	search:
+		for seed := uint32(0); ; seed++ {
+			if seed == phashMaxSeed {
+				return nil, nil, fmt.Errorf("no seed below %d places a bucket of %d keys in %d free slots; two keys may share a hash", phashMaxSeed, len(keys), freeSlots(taken))
+			}
+			tried = tried[:0]
+			for _, k := range keys {
+				s := phashSlot(hashes[k], seed, n)
+				if taken[s] || slices.Contains(tried, s) {
+					continue search
+				}
+				tried = append(tried, s)
+			}
+			for i, k := range keys {
+				taken[tried[i]] = true
+				slots[k] = tried[i]
+			}
+			seeds[b] = seed
+			break
+		}
+	}
+	return seeds, slots, nil
+}
+
+func freeSlots(taken []bool) int {
+	free := 0
+	for _, t := range taken {
+		if !t {
+			free++
+		}
+	}
+	return free
+}
+
+// WriteBarcodeIndex writes the optional perfect-hash barcode index next to
+// Pebble: every record in the store, reachable with one hash evaluation and
+// three reads from memory-mapped files (the bucket's seed, the slot's offset
+// and the record itself), so up to three page touches. Stores opened with
+// OpenReadOnly serve Get from it when present. Call it after all writes are
+// committed.
+func (s *Store) WriteBarcodeIndex() error {
+	dir := filepath.Join(s.dir, phashDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create barcode index dir: %w", err)
+	}
+
+	rf, err := os.Create(filepath.Join(dir, phashRecordsFile))
+	if err != nil {
+		return fmt.Errorf("create barcode records: %w", err)
+	}
+	defer rf.Close()
+	w := bufio.NewWriterSize(rf, 1<<20)
+
+	it, err := s.db.NewIter(nil)
+	if err != nil {
+		return fmt.Errorf("pebble iter: %w", err)
+	}
+	var (
+		hashes  []uint64
+		offsets []uint64
+		pos     uint64
+		hdr     [binary.MaxVarintLen64]byte
+	)
+	for valid := it.First(); valid; valid = it.Next() {
+		key, val := it.Key(), it.Value()
+		hashes = append(hashes, phashKey(key))
+		offsets = append(offsets, pos)
+		for _, b := range [][]byte{key, val} {
+			n := binary.PutUvarint(hdr[:], uint64(len(b)))
+			w.Write(hdr[:n])
+			w.Write(b)
+			pos += uint64(n + len(b))
+		}
+	}
+	if err := errors.Join(it.Error(), it.Close()); err != nil {
+		return fmt.Errorf("pebble iter: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write barcode records: %w", err)
+	}
+	if err := rf.Close(); err != nil {
+		return fmt.Errorf("close barcode records: %w", err)
+	}
+
+	seeds, slots, err := buildPerfectHash(hashes)
+	if err != nil {
+		return fmt.Errorf("build barcode perfect hash: %w", err)
+	}
+	bySlot := make([]uint64, phashTableSize(len(offsets)))
+	for i := range bySlot {
+		bySlot[i] = phashEmpty
+	}
+	for i, slot := range slots {
+		bySlot[slot] = offsets[i]
+	}
+
+	buf := make([]byte, 0, phashHeaderLen+4*len(seeds)+8*len(bySlot))
+	buf = append(buf, phashMagic...)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(bySlot)))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(seeds)))
+	for _, seed := range seeds {
+		buf = binary.LittleEndian.AppendUint32(buf, seed)
+	}
+	for _, off := range bySlot {
+		buf = binary.LittleEndian.AppendUint64(buf, off)
+	}
+	if err := os.WriteFile(filepath.Join(dir, phashIndexFile), buf, 0o644); err != nil {
+		return fmt.Errorf("write barcode index: %w", err)
+	}
+	return nil
+}
+
+// barcodeIndex serves lookups from the files written by WriteBarcodeIndex.
+type barcodeIndex struct {
+	n, m    uint64
+	seeds   []byte // m little-endian uint32
+	offsets []byte // n little-endian uint64
+	records []byte
+
+	maps  []mmap.MMap
+	files []*os.File
+}
+
+// openBarcodeIndex maps the perfect-hash barcode index. It returns (nil, nil)
+// when the data directory has none.
+func openBarcodeIndex(dataDir string) (*barcodeIndex, error) {
+	dir := filepath.Join(dataDir, phashDir)
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	bi := &barcodeIndex{}
+	index, err := bi.mapFile(filepath.Join(dir, phashIndexFile))
+	if err == nil {
+		bi.records, err = bi.mapFile(filepath.Join(dir, phashRecordsFile))
+	}
+	if err != nil {
+		_ = bi.Close()
+		return nil, fmt.Errorf("open barcode index: %w", err)
+	}
+
+	if len(index) < phashHeaderLen || (string(index[:8]) != phashMagic && string(index[:8]) != phashMagicV1) {
+		_ = bi.Close()
+		return nil, fmt.Errorf("open barcode index: bad header")
+	}
+	bi.n = binary.LittleEndian.Uint64(index[8:])
+	bi.m = binary.LittleEndian.Uint64(index[16:])
+	if want := phashHeaderLen + 4*bi.m + 8*bi.n; uint64(len(index)) != want {
+		_ = bi.Close()
+		return nil, fmt.Errorf("open barcode index: size %d, want %d", len(index), want)
+	}
+	bi.seeds = index[phashHeaderLen : phashHeaderLen+4*bi.m]
+	bi.offsets = index[phashHeaderLen+4*bi.m:]
+	return bi, nil
+}
+
+// mapFile maps path read-only; empty files map to nil.
+func (bi *barcodeIndex) mapFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	bi.files = append(bi.files, f)
+	if fi, err := f.Stat(); err != nil || fi.Size() == 0 {
+		return nil, err
+	}
+	mm, err := mmap.Map(f, mmap.RDONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("mmap %s: %w", filepath.Base(path), err)
+	}
+	bi.maps = append(bi.maps, mm)
+	return mm, nil
+}
+
+func (bi *barcodeIndex) Close() error {
+	var errs []error
+	for _, mm := range bi.maps {
+		errs = append(errs, mm.Unmap())
+	}
+	for _, f := range bi.files {
+		errs = append(errs, f.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// lookup returns the encoded record of barcode. The slice points into the
+// mapping and is valid until Close.
+func (bi *barcodeIndex) lookup(barcode string) ([]byte, bool, error) {
+	if bi.n == 0 {
+		return nil, false, nil
+	}
+	key := []byte(barcode)
+	h := phashKey(key)
+	seed := binary.LittleEndian.Uint32(bi.seeds[4*(h%bi.m):])
+	slot := phashSlot(h, seed, bi.n)
+	off := binary.LittleEndian.Uint64(bi.offsets[8*slot:])
+	if off == phashEmpty {
+		return nil, false, nil
+	}
+	if off >= uint64(len(bi.records)) {
+		return nil, false, fmt.Errorf("barcode index: offset %d out of range", off)
+	}
+
+	// Any string hashes to some slot; the stored barcode tells whether it is
+	// the one asked for.
+	rec := bi.records[off:]
+	klen, n := binary.Uvarint(rec)
+	if n <= 0 || uint64(len(rec)-n) < klen {
+		return nil, false, fmt.Errorf("barcode index: corrupt record at %d", off)
+	}
+	if !bytes.Equal(rec[n:n+int(klen)], key) {
+		return nil, false, nil
+	}
+	rec = rec[n+int(klen):]
+	vlen, n := binary.Uvarint(rec)
+	if n <= 0 || uint64(len(rec)-n) < vlen {
+		return nil, false, fmt.Errorf("barcode index: corrupt record at %d", off)
+	}
+	return rec[n : n+int(vlen)], true, nil
+}
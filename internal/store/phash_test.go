@@ -0,0 +1,129 @@
+package store
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildPerfectHash(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 10, 1000, 20_000} {
+		hashes := make([]uint64, n)
+		for i := range hashes {
+			hashes[i] = phashKey([]byte(fmt.Sprintf("%013d", i)))
+		}
+		seeds, slots, err := buildPerfectHash(hashes)
+		if err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		seen := make([]bool, phashTableSize(n))
+		for i, slot := range slots {
+			if seen[slot] {
+				t.Fatalf("n=%d: slot %d assigned twice", n, slot)
+			}
+			seen[slot] = true
+			m := uint64(len(seeds))
+			if got := phashSlot(hashes[i], seeds[hashes[i]%m], phashTableSize(n)); got != slot {
+				t.Fatalf("n=%d: key %d evaluates to slot %d, built as %d", n, i, got, slot)
+			}
+		}
+	}
+}
+
+// TestBuildPerfectHash_Large builds a table the size of a sizeable import;
+// with no spare slots the last buckets exhausted phashMaxSeed on runs like
+// this.
+func TestBuildPerfectHash_Large(t *testing.T) {
+	if testing.Short() {
+		t.Skip("large build")
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	hashes := make([]uint64, 500_000)
+	for i := range hashes {
+		hashes[i] = rng.Uint64()
+	}
+	seeds, slots, err := buildPerfectHash(hashes)
+	if err != nil {
+		t.Fatalf("buildPerfectHash: %v", err)
+	}
+	n, m := phashTableSize(len(hashes)), uint64(len(seeds))
+	seen := make([]bool, n)
+	for i, slot := range slots {
+		if seen[slot] {
+			t.Fatalf("slot %d assigned twice", slot)
+		}
+		seen[slot] = true
+		if got := phashSlot(hashes[i], seeds[hashes[i]%m], n); got != slot {
+			t.Fatalf("key %d evaluates to slot %d, built as %d", i, got, slot)
+		}
+	}
+}
+
+func TestBuildPerfectHash_DuplicateHash(t *testing.T) {
+	if _, _, err := buildPerfectHash([]uint64{42, 42}); err == nil {
+		t.Error("identical hashes built; want an error")
+	}
+}
+
+func TestGet_BarcodeIndex(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Create(dir)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	batch := s.NewWriteBatch()
+	for i := range 500 {
+		batch.Put(Product{Barcode: fmt.Sprintf("%013d", i), Name: fmt.Sprintf("Muesli %d", i), Kcal100g: float32(i)})
+	}
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close batch: %v", err)
+	}
+	if err := s.WriteBarcodeIndex(); err != nil {
+		t.Fatalf("WriteBarcodeIndex: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rs, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer rs.Close()
+	if rs.barcodes == nil {
+		t.Fatal("barcode index not opened")
+	}
+	for i := range 500 {
+		barcode := fmt.Sprintf("%013d", i)
+		p, ok, err := rs.Get(barcode)
+		if err != nil || !ok || p.Barcode != barcode || p.Name != fmt.Sprintf("Muesli %d", i) || p.Kcal100g != float32(i) {
+			t.Fatalf("Get(%s) = %+v, %v, %v", barcode, p, ok, err)
+		}
+	}
+	for _, barcode := range []string{"0000000000500", "x", "", "00000000000010"} {
+		if _, ok, err := rs.Get(barcode); err != nil || ok {
+			t.Errorf("Get(%q) = %v, %v; want a miss", barcode, ok, err)
+		}
+	}
+}
+
+func TestOpenBarcodeIndex_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, phashDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, phashDir, phashIndexFile), []byte("FFDBPH01short"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, phashDir, phashRecordsFile), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openBarcodeIndex(dir); err == nil {
+		t.Error("truncated index opened; want an error")
+	}
+	if bi, err := openBarcodeIndex(t.TempDir()); bi != nil || err != nil {
+		t.Errorf("missing index = %v, %v; want nil, nil", bi, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 )
 
@@ -15,22 +16,46 @@ type Product struct {
 	Protein  float32
 	Fat      float32
 	Carbs    float32
+
+	// Popularity is the log-damped OFF scan count, ln(1 + unique scans).
+	// Records written before schema version 2 decode as 0.
+	Popularity float32
+
+	// Brand is the first brand listed by OFF, "" when unknown (since v3).
+	Brand string
+
+	// Quality holds data-quality flags computed at import (since v4).
+	Quality QualityFlags
+
+	// Lang is the product's main language (OFF "lang"). It selects the
+	// stemmed Bleve sub-field and, like Barcode, is not stored in the blob.
+	Lang string
+
+	// Alternates lists the barcodes folded into this product by a collapsed
+	// search. Not stored.
+	Alternates []string
 }
 
-const schemaVersion = 1
+// SchemaVersion is the record layout version written by Encode. Decode also
+// accepts every earlier version.
+const SchemaVersion = 4
 
 // Encode serialises a Product into a compact binary format:
 //
-//	version   uvarint  (=1)
-//	nameLen   uvarint
-//	name      []byte (UTF-8)
-//	kcal100g  float32 LE  (NaN when missing)
-//	protein   float32 LE
-//	fat       float32 LE
-//	carbs     float32 LE
+//	version     uvarint  (=4)
+//	nameLen     uvarint
+//	name        []byte (UTF-8)
+//	kcal100g    float32 LE  (NaN when missing)
+//	protein     float32 LE
+//	fat         float32 LE
+//	carbs       float32 LE
+//	popularity  float32 LE  (since v2)
+//	brandLen    uvarint     (since v3)
+//	brand       []byte (UTF-8)
+//	quality     uvarint     (since v4)
 func (p Product) Encode() []byte {
 	var buf bytes.Buffer
-	writeUvarint(&buf, schemaVersion)
+	writeUvarint(&buf, SchemaVersion)
 
 	nameBytes := []byte(p.Name)
 	writeUvarint(&buf, uint64(len(nameBytes)))
@@ -40,6 +65,13 @@ func (p Product) Encode() []byte {
 	writeFloat32LE(&buf, p.Protein)
 	writeFloat32LE(&buf, p.Fat)
 	writeFloat32LE(&buf, p.Carbs)
+	writeFloat32LE(&buf, p.Popularity)
+
+	brandBytes := []byte(p.Brand)
+	writeUvarint(&buf, uint64(len(brandBytes)))
+	buf.Write(brandBytes)
+
+	writeUvarint(&buf, uint64(p.Quality))
 
 	return buf.Bytes()
 }
@@ -53,7 +85,7 @@ func (p *Product) Decode(data []byte) error {
 	if err != nil {
 		return fmt.Errorf("read version: %w", err)
 	}
-	if ver != schemaVersion {
+	if ver < 1 || ver > SchemaVersion {
 		return fmt.Errorf("unsupported schema version %d", ver)
 	}
 
@@ -83,6 +115,35 @@ func (p *Product) Decode(data []byte) error {
 	if err != nil {
 		return fmt.Errorf("read carbs: %w", err)
 	}
+	if ver < 2 {
+		return nil
+	}
+	p.Popularity, err = readFloat32LE(r)
+	if err != nil {
+		return fmt.Errorf("read popularity: %w", err)
+	}
+	if ver < 3 {
+		return nil
+	}
+
+	brandLen, err := binary.ReadUvarint(r)
+	if err != nil {
+		return fmt.Errorf("read brand length: %w", err)
+	}
+	brandBuf := make([]byte, brandLen)
+	if _, err := io.ReadFull(r, brandBuf); err != nil {
+		return fmt.Errorf("read brand: %w", err)
+	}
+	p.Brand = string(brandBuf)
+	if ver < 4 {
+		return nil
+	}
+
+	quality, err := binary.ReadUvarint(r)
+	if err != nil {
+		return fmt.Errorf("read quality: %w", err)
+	}
+	p.Quality = QualityFlags(quality)
 	return nil
 }
 
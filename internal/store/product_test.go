@@ -0,0 +1,38 @@
+package store
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestProductEncodeDecode(t *testing.T) {
+	in := Product{Name: "Coca-Cola", Kcal100g: 42, Protein: 0, Fat: 0, Carbs: 10.6, Popularity: 7.5, Brand: "Coca-Cola",
+		Quality: QualityKcalInferred | QualityMacrosExceed100g}
+	var out Product
+	if err := out.Decode(in.Encode()); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip = %+v; want %+v", out, in)
+	}
+}
+
+func TestProductDecode_V1(t *testing.T) {
+	// A version 1 record has no popularity field.
+	var buf bytes.Buffer
+	writeUvarint(&buf, 1)
+	writeUvarint(&buf, 4)
+	buf.WriteString("Milk")
+	for _, f := range []float32{64, 3.3, 3.6, 4.8} {
+		writeFloat32LE(&buf, f)
+	}
+
+	var p Product
+	if err := p.Decode(buf.Bytes()); err != nil {
+		t.Fatalf("Decode v1: %v", err)
+	}
+	if p.Name != "Milk" || p.Carbs != 4.8 || p.Popularity != 0 {
+		t.Errorf("Decode v1 = %+v", p)
+	}
+}
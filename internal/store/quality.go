@@ -0,0 +1,39 @@
+package store
+
+// QualityFlags is a bit set of data-quality issues detected at import time.
+type QualityFlags uint8
+
+const (
+	// QualityKcalInferred: kcal was missing and computed from the macros.
+	QualityKcalInferred QualityFlags = 1 << iota
+	// QualityEnergyMismatch: kcal is far from 4·protein + 4·carbs + 9·fat,
+	// e.g. a kJ value entered as kcal.
+	QualityEnergyMismatch
+	// QualityMacrosExceed100g: protein + fat + carbs add up to more than 100g.
+	QualityMacrosExceed100g
+)
+
+// qualityNames maps each flag to its API / index name, in bit order.
+var qualityNames = []struct {
+	flag QualityFlags
+	name string
+}{
+	{QualityKcalInferred, "kcal_inferred"},
+	{QualityEnergyMismatch, "energy_mismatch"},
+	{QualityMacrosExceed100g, "macros_exceed_100g"},
+}
+
+// LowQuality is the set of flags that mark a record as unreliable.
+// An inferred kcal is consistent by construction and is not included.
+const LowQuality = QualityEnergyMismatch | QualityMacrosExceed100g
+
+// Names returns the names of the set flags, in bit order.
+func (f QualityFlags) Names() []string {
+	var out []string
+	for _, q := range qualityNames {
+		if f&q.flag != 0 {
+			out = append(out, q.name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"slices"
+	"testing"
+)
+
+// relevanceCorpus is the shared fixture for every search backend. Barcodes
+// starting 400 are German, 300 French, 500 British and 540 the range Belgium
+// shares with Luxembourg.
+var relevanceCorpus = []Product{
+	{Barcode: "4000000000001", Name: "Dark Chocolate 70%", Kcal100g: 580, Popularity: 5},
+	{Barcode: "4000000000002", Name: "Milk Chocolate", Kcal100g: 535, Popularity: 8},
+	{Barcode: "4000000000003", Name: "Chocolate Milk Drink", Kcal100g: 70, Popularity: 2},
+	{Barcode: "4000000000004", Name: "Whole Milk", Kcal100g: 64, Popularity: 6},
+	{Barcode: "3000000000005", Name: "Lait Entier", Kcal100g: 64},
+	{Barcode: "4000000000006", Name: "Apple Juice", Kcal100g: 46},
+	{Barcode: "4000000000007", Name: "Apple Pie", Kcal100g: 237},
+	{Barcode: "4000000000008", Name: "Pineapple Chunks", Kcal100g: 60},
+	{Barcode: "5000000000009", Name: "Strawberry Yoghurt", Kcal100g: 98},
+	{Barcode: "4000000000010", Name: "Greek Yoghurt Plain", Kcal100g: 970, Quality: QualityEnergyMismatch},
+	{Barcode: "4000000000011", Name: "Peanut Butter Crunchy", Kcal100g: 600},
+	{Barcode: "4000000000012", Name: "Oat Bread", Kcal100g: 250},
+	{Barcode: "4000000000013", Name: "Tomato Soup", Kcal100g: 40},
+	{Barcode: "4000000000014", Name: "Tomato Ketchup", Kcal100g: 100, Popularity: 9},
+	{Barcode: "4000000000015", Name: "Sparkling Water", Brand: "Aqua", Kcal100g: 0},
+	{Barcode: "4000000000016", Name: "Sparkling Water", Brand: "Aqua", Kcal100g: 0},
+	{Barcode: "5400000000017", Name: "Speculoos Biscuits", Kcal100g: 484},
+}
+
+// relevanceCases are the queries every backend must answer alike.
+var relevanceCases = []struct {
+	name       string
+	query      string
+	opts       SearchOptions
+	top        string   // expected first result, "" for no constraint
+	inTop      []string // expected among the first len(inTop) results
+	absent     []string // must not be returned
+	empty      bool     // expect no results at all
+	alternates int      // expected len(Alternates) of the first result
+}{
+	{name: "exact phrase", query: "dark chocolate", top: "4000000000001"},
+	{name: "word order", query: "milk chocolate", top: "4000000000002"},
+	{name: "typo", query: "choclate", inTop: []string{"4000000000001", "4000000000002", "4000000000003"}},
+	{name: "accents folded", query: "Lait entièr", top: "3000000000005"},
+	{name: "whole word beats substring", query: "apple", inTop: []string{"4000000000006", "4000000000007"}},
+	{name: "multi word", query: "peanut butter", top: "4000000000011"},
+	{name: "single word", query: "bread", top: "4000000000012"},
+	{name: "popularity breaks near ties", query: "tomato", top: "4000000000014", inTop: []string{"4000000000013", "4000000000014"}},
+	{name: "low quality excluded", query: "yoghurt", opts: SearchOptions{ExcludeLowQuality: true},
+		top: "5000000000009", absent: []string{"4000000000010"}},
+	{name: "gs1 country filter", query: "lait", opts: SearchOptions{GS1Country: "fr"}, top: "3000000000005"},
+	{name: "gs1 country excludes", query: "lait", opts: SearchOptions{GS1Country: "DE"}, empty: true},
+	{name: "gs1 shared range", query: "speculoos", opts: SearchOptions{GS1Country: "LU"}, top: "5400000000017"},
+	{name: "gs1 shared range owner", query: "speculoos", opts: SearchOptions{GS1Country: "be"}, top: "5400000000017"},
+	{name: "no match", query: "xyzzy", empty: true},
+	{name: "collapse duplicates", query: "sparkling water", opts: SearchOptions{Collapse: true}, alternates: 1},
+}
+
+func TestRelevance(t *testing.T) {
+	dir := t.TempDir()
+	ws, err := Create(dir)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	batch := ws.NewWriteBatch()
+	for _, p := range relevanceCorpus {
+		batch.Put(p)
+	}
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close batch: %v", err)
+	}
+	if err := ws.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	rs, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer rs.Close()
+
+	for _, backend := range []string{SearchBackendBleve, SearchBackendTrigram} {
+		searcher, err := NewSearcher(backend, rs)
+		if err != nil {
+			t.Fatalf("NewSearcher(%s): %v", backend, err)
+		}
+		for _, tc := range relevanceCases {
+			t.Run(backend+"/"+tc.name, func(t *testing.T) {
+				results, err := searcher.SearchWith(tc.query, tc.opts)
+				if err != nil {
+					t.Fatalf("SearchWith(%q): %v", tc.query, err)
+				}
+				var got []string
+				for _, p := range results {
+					got = append(got, p.Barcode)
+				}
+				if tc.empty {
+					if len(got) != 0 {
+						t.Errorf("results = %v; want none", got)
+					}
+					return
+				}
+				if len(got) == 0 {
+					t.Fatal("no results")
+				}
+				if tc.top != "" && got[0] != tc.top {
+					t.Errorf("top = %s; want %s (results %v)", got[0], tc.top, got)
+				}
+				for _, want := range tc.inTop {
+					if !slices.Contains(got[:min(len(tc.inTop), len(got))], want) {
+						t.Errorf("%s not in top %d of %v", want, len(tc.inTop), got)
+					}
+				}
+				for _, bad := range tc.absent {
+					if slices.Contains(got, bad) {
+						t.Errorf("%s returned; want it filtered out (results %v)", bad, got)
+					}
+				}
+				if n := len(results[0].Alternates); n != tc.alternates {
+					t.Errorf("top result has %d alternates; want %d", n, tc.alternates)
+				}
+			})
+		}
+	}
+}
+
+func TestNewSearcher_Unknown(t *testing.T) {
+	if _, err := NewSearcher("solr", nil); err == nil {
+		t.Error("unknown backend accepted")
+	}
+}
+
+func TestTrigrams(t *testing.T) {
+	got := trigrams("oat oat")
+	if len(got) != 3 { // " oa", "oat", "at "; repeated words add nothing
+		t.Errorf("trigrams(oat oat) = %d; want 3", len(got))
+	}
+	if len(trigrams("молоко")) != 6 {
+		t.Errorf("trigrams(молоко) = %d; want 6 rune trigrams", len(trigrams("молоко")))
+	}
+	if trigrams("   ") != nil {
+		t.Error("trigrams of blank input not empty")
+	}
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// ScanPrefix returns up to limit products whose barcode starts with prefix,
+// in barcode order, starting strictly after the barcode cursor ("" for the
+// first page). next is the cursor for the following page, or "" when the
+// range is exhausted. limit caps the page size (max 100).
+func (s *Store) ScanPrefix(prefix, cursor string, limit int) (products []Product, next string, err error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if cursor != "" && !strings.HasPrefix(cursor, prefix) {
+		return nil, "", fmt.Errorf("cursor %q is outside prefix %q", cursor, prefix)
+	}
+
+	lower := []byte(prefix)
+	if cursor != "" {
+		// Smallest key strictly greater than cursor.
+		lower = append([]byte(cursor), 0)
+	}
+	it, err := s.db.NewIter(&pebble.IterOptions{
+		LowerBound: lower,
+		UpperBound: prefixSuccessor([]byte(prefix)),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("pebble iter: %w", err)
+	}
+	defer it.Close()
+
+	for valid := it.First(); valid; valid = it.Next() {
+		if len(products) == limit {
+			next = products[len(products)-1].Barcode
+			break
+		}
+		var p Product
+		if err := p.Decode(it.Value()); err != nil {
+			return nil, "", fmt.Errorf("decode product %q: %w", it.Key(), err)
+		}
+		p.Barcode = string(it.Key())
+		products = append(products, p)
+	}
+	if err := it.Error(); err != nil {
+		return nil, "", fmt.Errorf("pebble iter: %w", err)
+	}
+	return products, next, nil
+}
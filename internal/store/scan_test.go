@@ -0,0 +1,53 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScanPrefix(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Create(dir)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer s.Close()
+
+	batch := s.NewWriteBatch()
+	for _, bc := range []string{"4000001", "4000002", "4000003", "4000010", "4001000", "3999999", "40000"} {
+		batch.Put(Product{Barcode: bc, Name: "P " + bc})
+	}
+	if err := batch.Close(); err != nil {
+		t.Fatalf("batch close: %v", err)
+	}
+
+	var pages [][]string
+	cursor := ""
+	for {
+		products, next, err := s.ScanPrefix("40000", cursor, 2)
+		if err != nil {
+			t.Fatalf("ScanPrefix: %v", err)
+		}
+		var page []string
+		for _, p := range products {
+			page = append(page, p.Barcode)
+			if p.Name != "P "+p.Barcode {
+				t.Errorf("product %q decoded name %q", p.Barcode, p.Name)
+			}
+		}
+		pages = append(pages, page)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	want := [][]string{{"40000", "4000001"}, {"4000002", "4000003"}, {"4000010"}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %v; want %v", pages, want)
+	}
+
+	if _, _, err := s.ScanPrefix("400", "3999999", 2); err == nil {
+		t.Error("expected error for cursor outside prefix")
+	}
+}
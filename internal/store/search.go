@@ -0,0 +1,260 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+const (
+	// rerankWindow is how many Bleve hits per requested result are fetched
+	// for the popularity rerank.
+	rerankWindow = 3
+
+	// popularityWeight scales Product.Popularity (ln(1 + scans)) into a score
+	// multiplier: 1 000 scans ≈ ×1.7, 100 000 scans ≈ ×2.2.
+	popularityWeight = 0.1
+
+	// maxCollapseWindow caps the Bleve hits fetched to fill a collapsed page.
+	maxCollapseWindow = 1_000
+)
+
+// SearchOptions tunes a single search.
+type SearchOptions struct {
+	Limit    int    // max results, default 20, capped at 100
+	Lang     string // request language for stemming and synonyms, "" for any
+	Collapse bool   // group near-duplicate products into one result
+
+	// GS1Country restricts results to barcodes registered with this GS1
+	// member organisation (ISO 3166-1 alpha-2, e.g. "DE").
+	GS1Country string
+
+	// ExcludeLowQuality drops products flagged with any LowQuality flag.
+	ExcludeLowQuality bool
+}
+
+// Search runs a Bleve query and fetches the matching products from Pebble.
+// limit caps the number of results (max 100).
+func (s *Store) Search(q string, limit int) ([]Product, error) {
+	return s.SearchWith(q, SearchOptions{Limit: limit})
+}
+
+// SearchWith is Search with per-request options. Concurrent searches for the
+// same folded query, limit and filters share one execution, and with the
+// result cache enabled repeated ones are answered from memory; callers must
+// not modify the returned products.
+func (s *Store) SearchWith(q string, opts SearchOptions) ([]Product, error) {
+	limit := searchLimit(opts.Limit)
+
+	folded := FoldName(q)
+	if folded == "" {
+		return nil, nil
+	}
+
+	key := searchKey(folded, limit, opts, s.synonyms.Version())
+	if s.cache != nil {
+		if ps, ok := s.cache.get(key); ok {
+			return ps, nil
+		}
+	}
+	return s.flight.do(key, func() ([]Product, error) {
+		ps, err := s.search(folded, limit, opts)
+		if err == nil && s.cache != nil {
+			s.cache.put(key, ps)
+		}
+		return ps, err
+	})
+}
+
+// search runs the Bleve query for a folded, non-empty query and fetches and
+// ranks up to limit products.
+func (s *Store) search(folded string, limit int, opts SearchOptions) ([]Product, error) {
+	bq := s.buildQuery(folded, opts)
+
+	// Rerank one window of hits by popularity. Collapsing may merge many
+	// hits into one result, so it widens the window until it has a full page
+	// or runs out of hits.
+	size := limit * rerankWindow
+	for {
+		hits, complete, err := s.rankHits(bq, size)
+		if err != nil {
+			return nil, err
+		}
+		if !opts.Collapse {
+			return s.loadHits(head(hits, limit)), nil
+		}
+		groups := collapseProducts(s.loadHits(hits))
+		if len(groups) >= limit || complete {
+			return head(groups, limit), nil
+		}
+		size = min(size*2, maxCollapseWindow)
+	}
+}
+
+// buildQuery assembles the boolean should-query for a folded search string.
+func (s *Store) buildQuery(folded string, opts SearchOptions) query.Query {
+	boolQ := bleve.NewBooleanQuery()
+
+	// Stage A – exact / prefix (high boost)
+	phraseQ := bleve.NewMatchPhraseQuery(folded)
+	phraseQ.SetField("name_folded")
+	phraseQ.SetBoost(10)
+	boolQ.AddShould(phraseQ)
+
+	prefixQ := bleve.NewPrefixQuery(folded)
+	prefixQ.SetField("name_folded")
+	prefixQ.SetBoost(5)
+	boolQ.AddShould(prefixQ)
+
+	// Stage B – per-token fuzzy (only for tokens ≥4 chars)
+	// Boost hierarchy: phrase(10) > prefix(5) > fuzz1(2) > fuzz2(1)
+	for _, token := range strings.Fields(folded) {
+		if len(token) < 4 {
+			continue
+		}
+		fuzz := 1
+		boost := 2.0
+		if len(token) >= 8 {
+			fuzz = 2
+			boost = 1.0
+		}
+		fuzzyQ := bleve.NewFuzzyQuery(token)
+		fuzzyQ.SetField("name_folded")
+		fuzzyQ.Fuzziness = fuzz
+		fuzzyQ.SetBoost(boost)
+		boolQ.AddShould(fuzzyQ)
+	}
+
+	// Stemmed – all query tokens must match the stemmed sub-field, with stop
+	// words dropped by the language analyzer. Without a request language
+	// every sub-field is tried, so each product matches in its own language.
+	langs := StemmedLangs
+	if stemmedField(opts.Lang) != "" {
+		langs = []string{strings.ToLower(opts.Lang)}
+	}
+	for _, l := range langs {
+		stemQ := bleve.NewMatchQuery(folded)
+		stemQ.SetField("name_" + l)
+		stemQ.Analyzer = l
+		stemQ.SetOperator(query.MatchQueryOperatorAnd)
+		stemQ.SetBoost(3)
+		boolQ.AddShould(stemQ)
+	}
+
+	// Synonyms – each alternative spelling of the query as a phrase, just
+	// below the original phrase, plus the bare alternative below prefix.
+	for _, exp := range s.synonyms.expand(folded, strings.ToLower(opts.Lang)) {
+		altQ := bleve.NewMatchPhraseQuery(exp.Query)
+		altQ.SetField("name_folded")
+		altQ.SetBoost(8)
+		boolQ.AddShould(altQ)
+
+		if exp.Phrase != exp.Query {
+			spanQ := bleve.NewMatchPhraseQuery(exp.Phrase)
+			spanQ.SetField("name_folded")
+			spanQ.SetBoost(4)
+			boolQ.AddShould(spanQ)
+		}
+	}
+
+	if opts.GS1Country == "" && !opts.ExcludeLowQuality {
+		return boolQ
+	}
+
+	// Filters – the relevance query must match; filters only narrow it.
+	filtered := bleve.NewBooleanQuery()
+	filtered.AddMust(boolQ)
+	if opts.GS1Country != "" {
+		countryQ := bleve.NewTermQuery(strings.ToUpper(opts.GS1Country))
+		countryQ.SetField("gs1_country")
+		filtered.AddMust(countryQ)
+	}
+	if opts.ExcludeLowQuality {
+		for _, name := range LowQuality.Names() {
+			flagQ := bleve.NewTermQuery(name)
+			flagQ.SetField("quality")
+			filtered.AddMustNot(flagQ)
+		}
+	}
+	return filtered
+}
+
+// rankedHit is a Bleve hit with its popularity-weighted score.
+type rankedHit struct {
+	id    string
+	score float64
+}
+
+// popularityBoost is the score multiplier for a popularity.
+func popularityBoost(popularity float32) float64 {
+	return 1 + popularityWeight*float64(popularity)
+}
+
+// rankHits runs q for the top size hits and returns them reranked by the
+// popularity stored in the index, and whether they are all the hits there
+// are (or all a collapsed page may fetch). Popularity only reorders the
+// window: a popular product ranked below it by text score stays out.
+func (s *Store) rankHits(q query.Query, size int) ([]rankedHit, bool, error) {
+	req := bleve.NewSearchRequestOptions(q, size, 0, false)
+	req.Fields = []string{"popularity"}
+	res, err := s.index.Search(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("bleve search: %w", err)
+	}
+
+	hits := make([]rankedHit, len(res.Hits))
+	for i, hit := range res.Hits {
+		popularity, _ := hit.Fields["popularity"].(float64)
+		hits[i] = rankedHit{id: hit.ID, score: hit.Score * popularityBoost(float32(popularity))}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	return hits, res.Total <= uint64(size) || size >= maxCollapseWindow, nil
+}
+
+// loadHits fetches the products of hits from Pebble concurrently, keeping
+// their order and skipping any that are missing.
+func (s *Store) loadHits(hits []rankedHit) []Product {
+	type result struct {
+		p     Product
+		found bool
+	}
+	out := make([]result, len(hits))
+	var wg sync.WaitGroup
+	wg.Add(len(hits))
+	for i, hit := range hits {
+		go func() {
+			defer wg.Done()
+			p, found, _ := s.get(hit.id)
+			out[i] = result{p, found}
+		}()
+	}
+	wg.Wait()
+
+	products := make([]Product, 0, len(out))
+	for _, r := range out {
+		if r.found {
+			products = append(products, r.p)
+		}
+	}
+	return products
+}
+
+// searchLimit applies the default (20) and cap (100) to a requested limit.
+func searchLimit(n int) int {
+	if n <= 0 {
+		return 20
+	}
+	return min(n, 100)
+}
+
+// head returns at most the first n elements of s.
+func head[T any](s []T, n int) []T {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blevesearch/vellum"
+	"github.com/blevesearch/vellum/levenshtein"
+)
+
+const suggestTermsFile = "terms.fst"
+
+// speller proposes corrections for misspelled query tokens from the term
+// dictionary (folded token → number of products containing it).
+type speller struct {
+	terms *vellum.FST
+	lev   *levenshtein.LevenshteinAutomatonBuilder
+}
+
+func newSpeller(terms *vellum.FST) (*speller, error) {
+	// Transpositions count as a single edit: "protien" → "protein".
+	lev, err := levenshtein.NewLevenshteinAutomatonBuilder(2, true)
+	if err != nil {
+		return nil, fmt.Errorf("levenshtein builder: %w", err)
+	}
+	return &speller{terms: terms, lev: lev}, nil
+}
+
+// correct returns the corrected form of a folded query and whether any token
+// changed. Tokens follow the same edit-distance policy as Store.Search:
+// shorter than 4 runes are kept as-is, 4–7 allow distance 1, 8+ allow 2.
+func (sp *speller) correct(folded string) (string, bool, error) {
+	tokens := strings.Fields(folded)
+	changed := false
+	for i, tok := range tokens {
+		fixed, err := sp.correctToken(tok)
+		if err != nil {
+			return "", false, err
+		}
+		if fixed != tok {
+			tokens[i] = fixed
+			changed = true
+		}
+	}
+	return strings.Join(tokens, " "), changed, nil
+}
+
+func (sp *speller) correctToken(tok string) (string, error) {
+	n := len([]rune(tok))
+	if n < 4 {
+		return tok, nil
+	}
+	if _, ok, err := sp.terms.Get([]byte(tok)); err != nil {
+		return "", fmt.Errorf("terms fst get: %w", err)
+	} else if ok {
+		return tok, nil
+	}
+
+	var fuzz uint8 = 1
+	if n >= 8 {
+		fuzz = 2
+	}
+	dfa, err := sp.lev.BuildDfa(tok, fuzz)
+	if err != nil {
+		return "", fmt.Errorf("build dfa: %w", err)
+	}
+
+	best, bestDist, bestFreq := tok, fuzz+1, uint64(0)
+	it, err := sp.terms.Search(dfa, nil, nil)
+	for err == nil {
+		key, freq := it.Current()
+		_, dist := dfa.MatchAndDistance(string(key))
+		if dist < bestDist || (dist == bestDist && freq > bestFreq) {
+			best, bestDist, bestFreq = string(key), dist, freq
+		}
+		err = it.Next()
+	}
+	if err != vellum.ErrIteratorDone {
+		return "", fmt.Errorf("terms fst search: %w", err)
+	}
+	return best, nil
+}
@@ -0,0 +1,46 @@
+package store
+
+import "testing"
+
+func TestDidYouMean(t *testing.T) {
+	dir := t.TempDir()
+
+	b := NewSuggestBuilder()
+	for _, n := range []string{
+		"Greek Yoghurt", "Greek Yoghurt", "Strawberry Yoghurt",
+		"Protein Bar", "Protein Shake", "Protein Yoghurt",
+		"Protean Mix", // two edits from "protien", must lose to "protein"
+		"Chocolate Milk",
+	} {
+		b.Add(n)
+	}
+	if err := b.Write(dir); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	sg, err := openSuggester(dir)
+	if err != nil {
+		t.Fatalf("openSuggester: %v", err)
+	}
+	defer sg.Close()
+	s := &Store{suggest: sg}
+
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"yoghrut protien", "yoghurt protein"},
+		{"Chocolat milk", "chocolate milk"},
+		{"chocolate milk", ""}, // all tokens known
+		{"xyz", ""},            // too short to correct
+		{"qqqqqqq", ""},        // no candidate
+	}
+	for _, tc := range tests {
+		got, err := s.DidYouMean(tc.q)
+		if err != nil {
+			t.Fatalf("DidYouMean(%q): %v", tc.q, err)
+		}
+		if got != tc.want {
+			t.Errorf("DidYouMean(%q) = %q; want %q", tc.q, got, tc.want)
+		}
+	}
+}
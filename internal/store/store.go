@@ -1,77 +1,292 @@
 package store
 
 import (
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 
 	"github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/simple"
+	"github.com/blevesearch/bleve/v2/analysis/lang/de"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/analysis/lang/es"
+	"github.com/blevesearch/bleve/v2/analysis/lang/fr"
+	"github.com/blevesearch/bleve/v2/analysis/lang/it"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ru"
 	"github.com/blevesearch/bleve/v2/mapping"
 	"github.com/cockroachdb/pebble"
+	"github.com/korjavin/fastfooddb/internal/barcode"
 )
 
 const (
 	pebbleDir = "pebble"
 	bleveDir  = "bleve"
+
+	// shardDirFormat names the shard sub-directories of bleveDir when the
+	// index is built with more than one shard. A single-shard index lives
+	// directly in bleveDir.
+	shardDirFormat = "shard-%03d"
 )
 
 // bleveDoc is the document structure indexed into Bleve.
+//
+// NameFolded is analysed with the simple analyzer for every product. The
+// folded name is also copied into the stemmed sub-field of the product's
+// language (name_en, name_de, …) when that language is supported.
+// GS1Country holds the keyword ISO codes derived from the barcode prefix
+// (two for ranges shared by several countries) and Quality the keyword
+// names of the product's data-quality flags. Popularity is stored, not
+// indexed, so search can rerank hits by it without reading Pebble.
 type bleveDoc struct {
-	NameFolded string `json:"name_folded"`
+	NameFolded string   `json:"name_folded"`
+	NameEn     string   `json:"name_en,omitempty"`
+	NameDe     string   `json:"name_de,omitempty"`
+	NameFr     string   `json:"name_fr,omitempty"`
+	NameEs     string   `json:"name_es,omitempty"`
+	NameIt     string   `json:"name_it,omitempty"`
+	NameRu     string   `json:"name_ru,omitempty"`
+	GS1Country []string `json:"gs1_country,omitempty"`
+	Quality    []string `json:"quality,omitempty"`
+	Popularity float32  `json:"popularity,omitempty"`
+}
+
+// StemmedLangs lists the languages that get a stemmed name sub-field.
+// Each code is also the name of the Bleve analyzer used for it.
+var StemmedLangs = []string{
+	en.AnalyzerName, de.AnalyzerName, fr.AnalyzerName,
+	es.AnalyzerName, it.AnalyzerName, ru.AnalyzerName,
+}
+
+// newBleveDoc builds the Bleve document for p.
+func newBleveDoc(p Product) bleveDoc {
+	folded := FoldName(p.Name)
+	doc := bleveDoc{NameFolded: folded}
+	if gs1, ok := barcode.GS1Country(p.Barcode); ok {
+		doc.GS1Country = gs1.Codes()
+	}
+	doc.Quality = p.Quality.Names()
+	doc.Popularity = p.Popularity
+	switch strings.ToLower(p.Lang) {
+	case en.AnalyzerName:
+		doc.NameEn = folded
+	case de.AnalyzerName:
+		doc.NameDe = folded
+	case fr.AnalyzerName:
+		doc.NameFr = folded
+	case es.AnalyzerName:
+		doc.NameEs = folded
+	case it.AnalyzerName:
+		doc.NameIt = folded
+	case ru.AnalyzerName:
+		doc.NameRu = folded
+	}
+	return doc
+}
+
+// stemmedField returns the Bleve field holding names stemmed for lang, or ""
+// when lang has no stemmed sub-field.
+func stemmedField(lang string) string {
+	lang = strings.ToLower(lang)
+	for _, l := range StemmedLangs {
+		if l == lang {
+			return "name_" + l
+		}
+	}
+	return ""
 }
 
 // Store wraps a Pebble KV store and a Bleve full-text index.
+//
+// The index may be split into shards by barcode hash. Writes go to the owning
+// shard; searches go through index, which for several shards is a
+// bleve.IndexAlias that queries every shard in parallel and merges the hits.
 type Store struct {
-	db    *pebble.DB
-	index bleve.Index
+	dir      string
+	db       *pebble.DB
+	opts     *pebble.Options // options db was opened with; used to build ingested tables
+	index    bleve.Index     // the only shard, or an alias over all of them
+	shards   []bleve.Index   // in shard order; shards[shardFor(barcode)] owns a doc
+	suggest  *suggester      // nil when the data dir has no suggest index
+	barcodes *barcodeIndex   // nil when the data dir has no perfect-hash index
+	synonyms *Synonyms       // nil when no synonyms file is configured
+	cache    *resultCache    // nil unless EnableCache was called
+	flight   searchFlight
+	offline  int  // shard count of an index built by CreateOffline; shards is nil then
+	writable bool // created by CreateSharded; flushed to SSTables on Close
 }
 
-// OpenReadOnly opens an existing data directory in read-only mode (for the server).
+// OpenReadOnly opens an existing data directory in read-only mode (for the
+// server) with default Pebble tuning.
 func OpenReadOnly(dataDir string) (*Store, error) {
-	db, err := pebble.Open(filepath.Join(dataDir, pebbleDir), &pebble.Options{
-		ReadOnly: true,
-	})
+	return OpenReadOnlyWith(dataDir, PebbleOptions{})
+}
+
+// OpenReadOnlyWith is OpenReadOnly with explicit Pebble cache settings.
+func OpenReadOnlyWith(dataDir string, po PebbleOptions) (*Store, error) {
+	opts, cache := po.pebbleOptions(true)
+	db, err := pebble.Open(filepath.Join(dataDir, pebbleDir), opts)
+	cache.Unref()
 	if err != nil {
 		return nil, fmt.Errorf("open pebble (read-only): %w", err)
 	}
 
-	idx, err := bleve.Open(filepath.Join(dataDir, bleveDir))
+	shards, err := openShards(filepath.Join(dataDir, bleveDir))
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	sg, err := openSuggester(dataDir)
 	if err != nil {
+		closeAll(shards)
 		_ = db.Close()
-		return nil, fmt.Errorf("open bleve index: %w", err)
+		return nil, fmt.Errorf("open suggest index: %w", err)
 	}
 
-	return &Store{db: db, index: idx}, nil
+	bi, err := openBarcodeIndex(dataDir)
+	if err != nil {
+		if sg != nil {
+			_ = sg.Close()
+		}
+		closeAll(shards)
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Store{dir: dataDir, db: db, index: aliasShards(shards), shards: shards, suggest: sg, barcodes: bi}, nil
 }
 
-// Create initialises a fresh data directory for the importer.
+// openShards opens a single index in dir or, when dir holds shard
+// sub-directories, every shard in order.
+func openShards(dir string) ([]bleve.Index, error) {
+	if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf(shardDirFormat, 0))); err != nil {
+		idx, err := bleve.Open(dir)
+		if err != nil {
+			return nil, fmt.Errorf("open bleve index: %w", err)
+		}
+		return []bleve.Index{idx}, nil
+	}
+
+	var shards []bleve.Index
+	for i := 0; ; i++ {
+		shardDir := filepath.Join(dir, fmt.Sprintf(shardDirFormat, i))
+		if _, err := os.Stat(shardDir); err != nil {
+			break
+		}
+		idx, err := bleve.Open(shardDir)
+		if err != nil {
+			closeAll(shards)
+			return nil, fmt.Errorf("open bleve shard %d: %w", i, err)
+		}
+		shards = append(shards, idx)
+	}
+	return shards, nil
+}
+
+// aliasShards returns the index to search: the shard itself when there is
+// only one, otherwise an alias fanning out over all of them.
+func aliasShards(shards []bleve.Index) bleve.Index {
+	if len(shards) == 1 {
+		return shards[0]
+	}
+	return bleve.NewIndexAlias(shards...)
+}
+
+func closeAll(shards []bleve.Index) {
+	for _, idx := range shards {
+		_ = idx.Close()
+	}
+}
+
+// Create initialises a fresh data directory with a single-shard index.
 // The pebble and bleve sub-directories must not already exist.
 func Create(dataDir string) (*Store, error) {
-	db, err := pebble.Open(filepath.Join(dataDir, pebbleDir), &pebble.Options{})
+	return CreateSharded(dataDir, 1)
+}
+
+// CreateSharded initialises a fresh data directory for the importer whose
+// Bleve index is split into n shards by barcode hash.
+func CreateSharded(dataDir string, n int) (*Store, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid shard count %d", n)
+	}
+	opts, cache := PebbleOptions{}.pebbleOptions(false)
+	db, err := pebble.Open(filepath.Join(dataDir, pebbleDir), opts)
+	cache.Unref()
 	if err != nil {
 		return nil, fmt.Errorf("create pebble: %w", err)
 	}
 
-	idx, err := bleve.New(filepath.Join(dataDir, bleveDir), newBleveMapping())
-	if err != nil {
-		_ = db.Close()
-		return nil, fmt.Errorf("create bleve index: %w", err)
+	dir := filepath.Join(dataDir, bleveDir)
+	shards := make([]bleve.Index, 0, n)
+	for i := range n {
+		shardDir := dir
+		if n > 1 {
+			shardDir = filepath.Join(dir, fmt.Sprintf(shardDirFormat, i))
+		}
+		idx, err := bleve.New(shardDir, newBleveMapping())
+		if err != nil {
+			closeAll(shards)
+			_ = db.Close()
+			return nil, fmt.Errorf("create bleve shard %d: %w", i, err)
+		}
+		shards = append(shards, idx)
 	}
 
-	return &Store{db: db, index: idx}, nil
+	return &Store{dir: dataDir, db: db, opts: opts, index: aliasShards(shards), shards: shards, writable: true}, nil
+}
+
+// Shards returns the number of Bleve index shards.
+func (s *Store) Shards() int {
+	if s.offline > 0 {
+		return s.offline
+	}
+	return len(s.shards)
+}
+
+// shardFor returns the index of the shard owning barcode (FNV-1a hash).
+func (s *Store) shardFor(barcode string) int {
+	n := s.Shards()
+	if n == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(barcode))
+	return int(h.Sum32() % uint32(n))
 }
 
 // Close releases all resources held by the store.
 func (s *Store) Close() error {
 	var errs []string
-	if err := s.index.Close(); err != nil {
-		errs = append(errs, "bleve: "+err.Error())
+	for i, idx := range s.shards {
+		if err := idx.Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("bleve shard %d: %s", i, err))
+		}
+	}
+	if s.writable {
+		// Flush the memtable so readers get SSTables with bloom filters
+		// instead of replaying the WAL into memory.
+		if err := s.db.Flush(); err != nil {
+			errs = append(errs, "pebble flush: "+err.Error())
+		}
 	}
 	if err := s.db.Close(); err != nil {
 		errs = append(errs, "pebble: "+err.Error())
 	}
+	if s.suggest != nil {
+		if err := s.suggest.Close(); err != nil {
+			errs = append(errs, "suggest: "+err.Error())
+		}
+	}
+	if s.barcodes != nil {
+		if err := s.barcodes.Close(); err != nil {
+			errs = append(errs, "barcode index: "+err.Error())
+		}
+	}
 	if len(errs) > 0 {
 		return fmt.Errorf("store close: %s", strings.Join(errs, "; "))
 	}
@@ -84,6 +299,9 @@ func (s *Store) Put(p Product) error {
 	if p.Barcode == "" {
 		return fmt.Errorf("product has empty barcode")
 	}
+	if s.offline > 0 {
+		return fmt.Errorf("store builds its index offline; use a WriteBatch")
+	}
 
 	encoded := p.Encode()
 	if err := s.db.Set([]byte(p.Barcode), encoded, pebble.NoSync); err != nil {
@@ -91,8 +309,7 @@ func (s *Store) Put(p Product) error {
 	}
 
 	if p.Name != "" {
-		doc := bleveDoc{NameFolded: FoldName(p.Name)}
-		if err := s.index.Index(p.Barcode, doc); err != nil {
+		if err := s.shards[s.shardFor(p.Barcode)].Index(p.Barcode, newBleveDoc(p)); err != nil {
 			return fmt.Errorf("bleve index: %w", err)
 		}
 	}
@@ -100,57 +317,148 @@ func (s *Store) Put(p Product) error {
 }
 
 // WriteBatch accumulates products for batched writes to Pebble and Bleve.
+// A batch from NewIngestBatch sends Pebble records to sorter instead of pb,
+// and a batch of a CreateOffline store sends index documents to docs
+// instead of bb.
 type WriteBatch struct {
-	s     *Store
-	pb    *pebble.Batch
-	bb    *bleve.Batch
-	count int
+	s      *Store
+	pb     *pebble.Batch  // nil for ingest batches
+	sorter *kvSorter      // nil for regular batches
+	bb     []*bleve.Batch // one per shard; empty for offline index builds
+	docs   *kvSorter      // nil unless the index is built offline
+	err    error          // first sorter error, reported by Flush and Close
+	count  int
 }
 
 // NewWriteBatch creates a new WriteBatch backed by the given store.
 func (s *Store) NewWriteBatch() *WriteBatch {
-	return &WriteBatch{
+	b := &WriteBatch{
 		s:  s,
 		pb: s.db.NewBatch(),
-		bb: s.index.NewBatch(),
+		bb: make([]*bleve.Batch, len(s.shards)),
+	}
+	for i, idx := range s.shards {
+		b.bb[i] = idx.NewBatch()
+	}
+	if s.offline > 0 {
+		b.docs = newKVSorter(filepath.Join(s.dir, indexBuildDir), ingestRunBytes)
 	}
+	return b
 }
 
 // Put accumulates a product in the batch without flushing.
 func (b *WriteBatch) Put(p Product) {
 	encoded := p.Encode()
-	_ = b.pb.Set([]byte(p.Barcode), encoded, pebble.NoSync)
+	if b.sorter != nil {
+		if b.err == nil {
+			b.err = b.sorter.add([]byte(p.Barcode), encoded)
+		}
+	} else {
+		_ = b.pb.Set([]byte(p.Barcode), encoded, pebble.NoSync)
+	}
 	if p.Name != "" {
-		doc := bleveDoc{NameFolded: FoldName(p.Name)}
-		_ = b.bb.Index(p.Barcode, doc)
+		if b.docs != nil {
+			doc, _ := json.Marshal(newBleveDoc(p))
+			b.addDoc(p.Barcode, doc)
+		} else {
+			_ = b.bb[b.s.shardFor(p.Barcode)].Index(p.Barcode, newBleveDoc(p))
+		}
 	}
 	b.count++
 }
 
+// addDoc queues an encoded index document, or an unindex marker when doc is
+// empty, for the offline build.
+func (b *WriteBatch) addDoc(barcode string, doc []byte) {
+	if b.err == nil {
+		b.err = b.docs.add([]byte(barcode), doc)
+	}
+}
+
+// Unindex removes barcode from the text index in this batch while keeping its
+// Pebble record. The importer uses it when a duplicate without a name replaces
+// an indexed one.
+func (b *WriteBatch) Unindex(barcode string) {
+	if b.docs != nil {
+		b.addDoc(barcode, nil)
+		return
+	}
+	b.bb[b.s.shardFor(barcode)].Delete(barcode)
+}
+
 // Flush commits both batches to the underlying stores and resets accumulators.
+// Shards are committed in parallel. Ingest batches and offline index builds
+// keep their records in the sorters until Close.
 func (b *WriteBatch) Flush() error {
-	if err := b.pb.Commit(pebble.NoSync); err != nil {
-		return fmt.Errorf("pebble batch commit: %w", err)
+	if b.err != nil {
+		return b.err
 	}
-	if err := b.s.index.Batch(b.bb); err != nil {
-		return fmt.Errorf("bleve batch commit: %w", err)
+	if b.pb != nil {
+		if err := b.pb.Commit(pebble.NoSync); err != nil {
+			return fmt.Errorf("pebble batch commit: %w", err)
+		}
+	}
+
+	errs := make([]error, len(b.bb))
+	var wg sync.WaitGroup
+	for i, bb := range b.bb {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = b.s.shards[i].Batch(bb)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("bleve batch commit (shard %d): %w", i, err)
+		}
+	}
+
+	if b.pb != nil {
+		b.pb.Reset()
+	}
+	for i, idx := range b.s.shards {
+		b.bb[i] = idx.NewBatch()
 	}
-	b.pb.Reset()
-	b.bb = b.s.index.NewBatch()
 	b.count = 0
 	return nil
 }
 
-// Close flushes any pending data and releases the pebble batch memory.
+// Close flushes any pending data and releases the pebble batch memory. For
+// ingest batches it also builds and ingests the sorted SSTables, and for
+// offline index builds it builds the text index.
 func (b *WriteBatch) Close() error {
-	if b.count > 0 {
-		if err := b.Flush(); err != nil {
-			b.pb.Close()
-			return err
+	var err error
+	if b.count > 0 || b.err != nil {
+		err = b.Flush()
+	}
+	if b.pb != nil {
+		b.pb.Close()
+	}
+	if b.sorter != nil {
+		if err == nil {
+			err = b.s.ingestSorted(b.sorter)
 		}
+		err = finishSorter(b.sorter, err)
 	}
-	b.pb.Close()
-	return nil
+	if b.docs != nil {
+		if err == nil {
+			err = b.s.buildIndex(b.docs)
+		}
+		err = finishSorter(b.docs, err)
+	}
+	return err
+}
+
+// finishSorter removes a sorter's scratch directory, returning err or else
+// any error from the removal.
+func finishSorter(s *kvSorter, err error) error {
+	s.cleanup()
+	if rerr := os.RemoveAll(s.dir); err == nil && rerr != nil {
+		err = fmt.Errorf("remove %s: %w", filepath.Base(s.dir), rerr)
+	}
+	return err
 }
 
 // Len returns the number of records accumulated since the last flush.
@@ -158,9 +466,57 @@ func (b *WriteBatch) Len() int {
 	return b.count
 }
 
-// Get retrieves a product by barcode from Pebble.
-// Returns (Product, false, nil) when the barcode is not found.
+// Get retrieves a product by barcode, from the result cache when enabled or
+// else from the perfect-hash index or Pebble. Returns (Product, false, nil)
+// when the barcode is not found.
 func (s *Store) Get(barcode string) (Product, bool, error) {
+	if s.cache == nil {
+		return s.get(barcode)
+	}
+	key := getCacheKey(barcode)
+	if ps, ok := s.cache.get(key); ok {
+		if len(ps) == 0 {
+			return Product{}, false, nil
+		}
+		return ps[0], true, nil
+	}
+	p, found, err := s.get(barcode)
+	if err != nil {
+		return Product{}, false, err
+	}
+	if found {
+		s.cache.put(key, []Product{p})
+	} else {
+		s.cache.put(key, nil)
+	}
+	return p, found, nil
+}
+
+// get reads a product from the perfect-hash index when the data dir has one,
+// otherwise from Pebble.
+func (s *Store) get(barcode string) (Product, bool, error) {
+	if s.barcodes != nil {
+		return s.getIndexed(barcode)
+	}
+	return s.getPebble(barcode)
+}
+
+// getIndexed looks barcode up in the memory-mapped perfect-hash index.
+func (s *Store) getIndexed(barcode string) (Product, bool, error) {
+	val, ok, err := s.barcodes.lookup(barcode)
+	if err != nil || !ok {
+		return Product{}, false, err
+	}
+	var p Product
+	if err := p.Decode(val); err != nil {
+		return Product{}, false, fmt.Errorf("decode product: %w", err)
+	}
+	p.Barcode = barcode
+	return p, true, nil
+}
+
+// getPebble reads a product from Pebble.
+func (s *Store) getPebble(barcode string) (Product, bool, error) {
 	val, closer, err := s.db.Get([]byte(barcode))
 	if err == pebble.ErrNotFound {
 		return Product{}, false, nil
@@ -182,85 +538,45 @@ func (s *Store) Get(barcode string) (Product, bool, error) {
 	return p, true, nil
 }
 
-// Search runs a Bleve query and fetches the matching products from Pebble.
-// limit caps the number of results (max 100).
-func (s *Store) Search(q string, limit int) ([]Product, error) {
-	if limit <= 0 {
-		limit = 20
+// SetSynonyms installs a query-time synonym dictionary. It must be called
+// before the store starts serving searches; the dictionary itself may be
+// reloaded concurrently.
+func (s *Store) SetSynonyms(sy *Synonyms) {
+	s.synonyms = sy
+}
+
+// Suggest returns up to k autocomplete completions for the folded prefix of q,
+// heaviest first. It never touches Bleve. k is capped at SuggestMaxK.
+func (s *Store) Suggest(q string, k int) ([]Suggestion, error) {
+	if s.suggest == nil {
+		return nil, ErrSuggestUnavailable
 	}
-	if limit > 100 {
-		limit = 100
+	if k <= 0 || k > SuggestMaxK {
+		k = SuggestMaxK
 	}
-
 	folded := FoldName(q)
 	if folded == "" {
 		return nil, nil
 	}
+	return s.suggest.suggest(folded, k)
+}
 
-	boolQ := bleve.NewBooleanQuery()
-
-	// Stage A – exact / prefix (high boost)
-	phraseQ := bleve.NewMatchPhraseQuery(folded)
-	phraseQ.SetField("name_folded")
-	phraseQ.SetBoost(10)
-	boolQ.AddShould(phraseQ)
-
-	prefixQ := bleve.NewPrefixQuery(folded)
-	prefixQ.SetField("name_folded")
-	prefixQ.SetBoost(5)
-	boolQ.AddShould(prefixQ)
-
-	// Stage B – per-token fuzzy (only for tokens ≥4 chars)
-	// Boost hierarchy: phrase(10) > prefix(5) > fuzz1(2) > fuzz2(1)
-	for _, token := range strings.Fields(folded) {
-		if len(token) < 4 {
-			continue
-		}
-		fuzz := 1
-		boost := 2.0
-		if len(token) >= 8 {
-			fuzz = 2
-			boost = 1.0
-		}
-		fuzzyQ := bleve.NewFuzzyQuery(token)
-		fuzzyQ.SetField("name_folded")
-		fuzzyQ.Fuzziness = fuzz
-		fuzzyQ.SetBoost(boost)
-		boolQ.AddShould(fuzzyQ)
-	}
-
-	req := bleve.NewSearchRequestOptions(boolQ, limit, 0, false)
-	res, err := s.index.Search(req)
-	if err != nil {
-		return nil, fmt.Errorf("bleve search: %w", err)
-	}
-
-	// Parallel fan-out: fetch each hit from Pebble concurrently.
-	// Indexed slots preserve Bleve score order.
-	type result struct {
-		p     Product
-		found bool
+// DidYouMean returns a corrected query built from per-token edit-distance
+// candidates weighted by term frequency, or "" when every token is already
+// known or no better candidate exists. The result is folded.
+func (s *Store) DidYouMean(q string) (string, error) {
+	if s.suggest == nil || s.suggest.spell == nil {
+		return "", nil
 	}
-	out := make([]result, len(res.Hits))
-	var wg sync.WaitGroup
-	wg.Add(len(res.Hits))
-	for i, hit := range res.Hits {
-		i, id := i, hit.ID
-		go func() {
-			defer wg.Done()
-			p, found, _ := s.Get(id)
-			out[i] = result{p, found}
-		}()
+	folded := FoldName(q)
+	if folded == "" {
+		return "", nil
 	}
-	wg.Wait()
-
-	products := make([]Product, 0, len(out))
-	for _, r := range out {
-		if r.found {
-			products = append(products, r.p)
-		}
+	corrected, changed, err := s.suggest.spell.correct(folded)
+	if err != nil || !changed {
+		return "", err
 	}
-	return products, nil
+	return corrected, nil
 }
 
 // newBleveMapping builds the index mapping used when creating a fresh index.
@@ -274,6 +590,28 @@ func newBleveMapping() mapping.IndexMapping {
 	docMapping := bleve.NewDocumentMapping()
 	docMapping.AddFieldMappingsAt("name_folded", textField)
 
+	for _, l := range StemmedLangs {
+		langField := bleve.NewTextFieldMapping()
+		langField.Analyzer = l
+		langField.Store = false
+		langField.IncludeTermVectors = false // match queries only, no phrases
+		docMapping.AddFieldMappingsAt("name_"+l, langField)
+	}
+
+	keywordField := bleve.NewKeywordFieldMapping()
+	keywordField.Store = false
+	keywordField.IncludeTermVectors = false
+	keywordField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("gs1_country", keywordField)
+	docMapping.AddFieldMappingsAt("quality", keywordField)
+
+	popularityField := bleve.NewNumericFieldMapping()
+	popularityField.Index = false
+	popularityField.Store = true
+	popularityField.DocValues = false
+	popularityField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("popularity", popularityField)
+
 	im.DefaultMapping = docMapping
 	return im
 }
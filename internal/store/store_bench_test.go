@@ -50,14 +50,30 @@ func seedProducts(tb testing.TB, s *store.Store, n int) (midBarcode string) {
 // openReadOnly creates a seeded store, closes it (write mode), then reopens
 // read-only to simulate the real server environment.
 func openBenchStore(tb testing.TB, n int) (*store.Store, string) {
+	return openShardedBenchStore(tb, n, 1)
+}
+
+// openShardedBenchStore is openBenchStore with the index split into shards.
+func openShardedBenchStore(tb testing.TB, n, shards int) (*store.Store, string) {
+	return openBenchStoreWith(tb, n, shards, false)
+}
+
+// openBenchStoreWith optionally also writes the perfect-hash barcode index,
+// which the reopened store then serves Get from.
+func openBenchStoreWith(tb testing.TB, n, shards int, barcodeIndex bool) (*store.Store, string) {
 	tb.Helper()
 	dir := tb.TempDir()
 
-	ws, err := store.Create(dir)
+	ws, err := store.CreateSharded(dir, shards)
 	if err != nil {
 		tb.Fatalf("create store: %v", err)
 	}
 	mid := seedProducts(tb, ws, n)
+	if barcodeIndex {
+		if err := ws.WriteBarcodeIndex(); err != nil {
+			tb.Fatalf("write barcode index: %v", err)
+		}
+	}
 	if err := ws.Close(); err != nil {
 		tb.Fatalf("close write store: %v", err)
 	}
@@ -70,6 +86,7 @@ func openBenchStore(tb testing.TB, n int) (*store.Store, string) {
 	return rs, mid
 }
 
+// BenchmarkGet measures a barcode lookup that finds its product.
 func BenchmarkGet(b *testing.B) {
 	s, barcode := openBenchStore(b, 10_000)
 	b.ResetTimer()
@@ -86,6 +103,63 @@ func BenchmarkGet(b *testing.B) {
 	}
 }
 
+// BenchmarkGet_Miss measures lookups of barcodes that are not in the store,
+// a large share of scanner traffic. Table bloom filters let these skip the
+// SSTable index and data blocks.
+func BenchmarkGet_Miss(b *testing.B) {
+	s, _ := openBenchStore(b, 10_000)
+	// Inside the stored key range, so lookups reach the SSTables.
+	missing := make([]string, 64)
+	for i := range missing {
+		missing[i] = fmt.Sprintf("%013d", i*131+1) + "0"
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, found, err := s.Get(missing[i%len(missing)])
+		if err != nil {
+			b.Fatalf("Get: %v", err)
+		}
+		if found {
+			b.Fatalf("barcode %q unexpectedly found", missing[i%len(missing)])
+		}
+	}
+}
+
+// BenchmarkGet_Backends compares Pebble with the memory-mapped perfect-hash
+// barcode index for hits and misses over 100k products.
+func BenchmarkGet_Backends(b *testing.B) {
+	const n = 100_000
+	for _, backend := range []struct {
+		name         string
+		barcodeIndex bool
+	}{{"pebble", false}, {"phash", true}} {
+		s, _ := openBenchStoreWith(b, n, 1, backend.barcodeIndex)
+		hits := make([]string, 1024)
+		misses := make([]string, len(hits))
+		for i := range hits {
+			hits[i] = fmt.Sprintf("%013d", (i*97)%n+1)
+			misses[i] = hits[i] + "0"
+		}
+		for _, lookup := range []struct {
+			name  string
+			keys  []string
+			found bool
+		}{{"hit", hits, true}, {"miss", misses, false}} {
+			b.Run(backend.name+"/"+lookup.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					key := lookup.keys[i%len(lookup.keys)]
+					if _, found, err := s.Get(key); err != nil || found != lookup.found {
+						b.Fatalf("Get(%q) = %v, %v; want found=%v", key, found, err, lookup.found)
+					}
+				}
+			})
+		}
+	}
+}
+
 func BenchmarkSearch_CommonTerm(b *testing.B) {
 	s, _ := openBenchStore(b, 10_000)
 	b.ResetTimer()
@@ -101,6 +175,28 @@ func BenchmarkSearch_CommonTerm(b *testing.B) {
 	}
 }
 
+// BenchmarkSearch_Backends runs the same queries against the Bleve and
+// trigram search backends.
+func BenchmarkSearch_Backends(b *testing.B) {
+	s, _ := openBenchStore(b, 10_000)
+	queries := []string{"apple", "chicken pasta", "milk", "chocolate", "choclate"}
+	for _, backend := range []string{store.SearchBackendBleve, store.SearchBackendTrigram} {
+		searcher, err := store.NewSearcher(backend, s)
+		if err != nil {
+			b.Fatalf("NewSearcher(%s): %v", backend, err)
+		}
+		b.Run(backend, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				q := queries[i%len(queries)]
+				if _, err := searcher.SearchWith(q, store.SearchOptions{Limit: 20}); err != nil {
+					b.Fatalf("SearchWith(%q): %v", q, err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkSearch_FuzzyTerm(b *testing.B) {
 	s, _ := openBenchStore(b, 10_000)
 	b.ResetTimer()
@@ -117,6 +213,26 @@ func BenchmarkSearch_FuzzyTerm(b *testing.B) {
 	}
 }
 
+// BenchmarkSearch_Shards compares one monolithic index with indexes split into
+// shards that are queried in parallel through a bleve.IndexAlias.
+func BenchmarkSearch_Shards(b *testing.B) {
+	queries := []string{"apple", "chicken pasta", "chiken bred", "tomatto soup"}
+	for _, shards := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			s, _ := openShardedBenchStore(b, 50_000, shards)
+			b.ResetTimer()
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				q := queries[i%len(queries)]
+				if _, err := s.Search(q, 20); err != nil {
+					b.Fatalf("Search(%q): %v", q, err)
+				}
+			}
+		})
+	}
+}
+
 // Sanity check: ensure seeded data is retrievable (not run by bench runner).
 func TestBenchSeed_Sanity(t *testing.T) {
 	s, barcode := openBenchStore(t, 100)
@@ -0,0 +1,462 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blevesearch/vellum"
+)
+
+const (
+	suggestDir        = "suggest"
+	suggestNamesFile  = "names.fst"
+	suggestPrefixFST  = "prefixes.fst"
+	suggestPrefixData = "prefixes.dat"
+
+	// suggestPrefixRunes is the longest prefix (in runes) whose top-K
+	// completions are precomputed at import time. Longer prefixes are served
+	// by a bounded range scan over the names FST, which is narrow by then.
+	suggestPrefixRunes = 6
+
+	// SuggestMaxK is the number of completions stored per prefix and the
+	// upper bound for Store.Suggest.
+	SuggestMaxK = 10
+
+	// suggestMaxScan caps the number of names visited for long prefixes.
+	suggestMaxScan = 5_000
+)
+
+// ErrSuggestUnavailable is returned by Store.Suggest when the data directory
+// was built without a suggest index.
+var ErrSuggestUnavailable = errors.New("suggest index not available")
+
+// Suggestion is a single autocomplete completion.
+type Suggestion struct {
+	Text   string
+	Weight uint64
+}
+
+// SuggestBuilder collects folded product names during import and writes the
+// autocomplete and spelling FSTs into the data directory.
+type SuggestBuilder struct {
+	weights map[string]uint64 // folded name → products
+	terms   map[string]uint64 // folded token → products containing it
+}
+
+// NewSuggestBuilder returns an empty SuggestBuilder.
+func NewSuggestBuilder() *SuggestBuilder {
+	return &SuggestBuilder{
+		weights: make(map[string]uint64),
+		terms:   make(map[string]uint64),
+	}
+}
+
+// Add records one product name. The weight of a completion is the number of
+// products sharing its folded name; the frequency of a term is the number of
+// products whose folded name contains it.
+func (b *SuggestBuilder) Add(name string) {
+	folded := FoldName(name)
+	if folded == "" {
+		return
+	}
+	b.weights[folded]++
+
+	tokens := strings.Fields(folded)
+	for i, tok := range tokens {
+		if !slices.Contains(tokens[:i], tok) {
+			b.terms[tok]++
+		}
+	}
+}
+
+// Remove undoes one earlier Add of name, e.g. when the importer replaces a
+// duplicate barcode.
+func (b *SuggestBuilder) Remove(name string) {
+	folded := FoldName(name)
+	if b.weights[folded] == 0 {
+		return
+	}
+	decrement(b.weights, folded)
+
+	tokens := strings.Fields(folded)
+	for i, tok := range tokens {
+		if !slices.Contains(tokens[:i], tok) {
+			decrement(b.terms, tok)
+		}
+	}
+}
+
+// decrement lowers m[k] by one, dropping the key when it reaches zero.
+func decrement(m map[string]uint64, k string) {
+	if m[k] <= 1 {
+		delete(m, k)
+		return
+	}
+	m[k]--
+}
+
+// Len returns the number of distinct folded names collected so far.
+func (b *SuggestBuilder) Len() int {
+	return len(b.weights)
+}
+
+// Write builds the suggest directory inside dataDir:
+//
+//	names.fst     folded name → weight
+//	terms.fst     folded token → product frequency
+//	prefixes.fst  prefix (≤ suggestPrefixRunes runes) → offset into prefixes.dat
+//	prefixes.dat  per prefix: uvarint recordLen, then up to SuggestMaxK ×
+//	              (uvarint weight, uvarint textLen, text), heaviest first
+func (b *SuggestBuilder) Write(dataDir string) error {
+	dir := filepath.Join(dataDir, suggestDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create suggest dir: %w", err)
+	}
+
+	names := make([]string, 0, len(b.weights))
+	for n := range b.weights {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	if err := writeWeightFST(filepath.Join(dir, suggestNamesFile), b.weights); err != nil {
+		return fmt.Errorf("write names fst: %w", err)
+	}
+	if err := writeWeightFST(filepath.Join(dir, suggestTermsFile), b.terms); err != nil {
+		return fmt.Errorf("write terms fst: %w", err)
+	}
+	return b.writePrefixes(dir, names)
+}
+
+// writeWeightFST writes weights to a vellum FST at path in key order.
+func writeWeightFST(path string, weights map[string]uint64) error {
+	keys := make([]string, 0, len(weights))
+	for k := range weights {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Base(path), err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	fb, err := vellum.New(w, nil)
+	if err != nil {
+		return fmt.Errorf("new fst: %w", err)
+	}
+	for _, k := range keys {
+		if err := fb.Insert([]byte(k), weights[k]); err != nil {
+			return fmt.Errorf("insert %q: %w", k, err)
+		}
+	}
+	if err := fb.Close(); err != nil {
+		return fmt.Errorf("close fst: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flush %s: %w", filepath.Base(path), err)
+	}
+	return f.Close()
+}
+
+// writePrefixes walks the sorted names once. All names sharing a prefix are
+// contiguous, so an open aggregator per prefix depth is enough: when the
+// prefix at depth d changes, every aggregator from d upward is complete and
+// its top-K list is emitted.
+func (b *SuggestBuilder) writePrefixes(dir string, names []string) error {
+	df, err := os.Create(filepath.Join(dir, suggestPrefixData))
+	if err != nil {
+		return fmt.Errorf("create prefix data: %w", err)
+	}
+	defer df.Close()
+	dw := bufio.NewWriter(df)
+
+	type entry struct {
+		prefix string
+		offset uint64
+	}
+	var (
+		entries []entry
+		offset  uint64
+		open    []*topK
+		rec     bytes.Buffer
+	)
+
+	emit := func(t *topK) error {
+		rec.Reset()
+		for _, s := range t.items {
+			writeUvarint(&rec, s.Weight)
+			writeUvarint(&rec, uint64(len(s.Text)))
+			rec.WriteString(s.Text)
+		}
+		var hdr [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(hdr[:], uint64(rec.Len()))
+		if _, err := dw.Write(hdr[:n]); err != nil {
+			return err
+		}
+		if _, err := dw.Write(rec.Bytes()); err != nil {
+			return err
+		}
+		entries = append(entries, entry{prefix: t.prefix, offset: offset})
+		offset += uint64(n + rec.Len())
+		return nil
+	}
+
+	for _, name := range names {
+		prefixes := runePrefixes(name, suggestPrefixRunes)
+
+		// Close aggregators whose prefix no longer matches, deepest first.
+		keep := 0
+		for keep < len(open) && keep < len(prefixes) && open[keep].prefix == prefixes[keep] {
+			keep++
+		}
+		for d := len(open) - 1; d >= keep; d-- {
+			if err := emit(open[d]); err != nil {
+				return fmt.Errorf("write prefix data: %w", err)
+			}
+		}
+		open = open[:keep]
+		for _, p := range prefixes[keep:] {
+			open = append(open, &topK{prefix: p})
+		}
+
+		w := b.weights[name]
+		for _, t := range open {
+			t.add(Suggestion{Text: name, Weight: w})
+		}
+	}
+	for d := len(open) - 1; d >= 0; d-- {
+		if err := emit(open[d]); err != nil {
+			return fmt.Errorf("write prefix data: %w", err)
+		}
+	}
+	if err := dw.Flush(); err != nil {
+		return fmt.Errorf("flush prefix data: %w", err)
+	}
+	if err := df.Close(); err != nil {
+		return fmt.Errorf("close prefix data: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].prefix < entries[j].prefix })
+
+	ff, err := os.Create(filepath.Join(dir, suggestPrefixFST))
+	if err != nil {
+		return fmt.Errorf("create prefix fst: %w", err)
+	}
+	defer ff.Close()
+	fw := bufio.NewWriter(ff)
+	fb, err := vellum.New(fw, nil)
+	if err != nil {
+		return fmt.Errorf("new prefix fst: %w", err)
+	}
+	for _, e := range entries {
+		if err := fb.Insert([]byte(e.prefix), e.offset); err != nil {
+			return fmt.Errorf("insert prefix %q: %w", e.prefix, err)
+		}
+	}
+	if err := fb.Close(); err != nil {
+		return fmt.Errorf("close prefix fst: %w", err)
+	}
+	if err := fw.Flush(); err != nil {
+		return fmt.Errorf("flush prefix fst: %w", err)
+	}
+	return ff.Close()
+}
+
+// runePrefixes returns the prefixes of s of 1..max runes.
+func runePrefixes(s string, max int) []string {
+	out := make([]string, 0, max)
+	for i := range s {
+		if i == 0 {
+			continue
+		}
+		out = append(out, s[:i])
+		if len(out) == max {
+			return out
+		}
+	}
+	return append(out, s)
+}
+
+// topK keeps the K heaviest suggestions, ordered by weight desc then text asc.
+type topK struct {
+	prefix string
+	items  []Suggestion
+	k      int
+}
+
+func (t *topK) add(s Suggestion) {
+	k := t.k
+	if k == 0 {
+		k = SuggestMaxK
+	}
+	if len(t.items) == k && !heavier(s, t.items[k-1]) {
+		return
+	}
+	i := sort.Search(len(t.items), func(i int) bool { return heavier(s, t.items[i]) })
+	if len(t.items) < k {
+		t.items = append(t.items, Suggestion{})
+	}
+	copy(t.items[i+1:], t.items[i:])
+	t.items[i] = s
+}
+
+func heavier(a, b Suggestion) bool {
+	if a.Weight != b.Weight {
+		return a.Weight > b.Weight
+	}
+	return a.Text < b.Text
+}
+
+// suggester serves completions from the files written by SuggestBuilder.
+type suggester struct {
+	names    *vellum.FST
+	prefixes *vellum.FST
+	data     *os.File
+	spell    *speller // nil when terms.fst is absent
+}
+
+// openSuggester opens the suggest directory. It returns (nil, nil) when the
+// directory does not exist so older data directories keep working.
+func openSuggester(dataDir string) (*suggester, error) {
+	dir := filepath.Join(dataDir, suggestDir)
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	names, err := vellum.Open(filepath.Join(dir, suggestNamesFile))
+	if err != nil {
+		return nil, fmt.Errorf("open names fst: %w", err)
+	}
+	prefixes, err := vellum.Open(filepath.Join(dir, suggestPrefixFST))
+	if err != nil {
+		_ = names.Close()
+		return nil, fmt.Errorf("open prefix fst: %w", err)
+	}
+	data, err := os.Open(filepath.Join(dir, suggestPrefixData))
+	if err != nil {
+		_ = names.Close()
+		_ = prefixes.Close()
+		return nil, fmt.Errorf("open prefix data: %w", err)
+	}
+	sg := &suggester{names: names, prefixes: prefixes, data: data}
+
+	terms, err := vellum.Open(filepath.Join(dir, suggestTermsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return sg, nil
+	}
+	if err != nil {
+		_ = sg.Close()
+		return nil, fmt.Errorf("open terms fst: %w", err)
+	}
+	sg.spell, err = newSpeller(terms)
+	if err != nil {
+		_ = terms.Close()
+		_ = sg.Close()
+		return nil, err
+	}
+	return sg, nil
+}
+
+func (sg *suggester) Close() error {
+	errs := []error{sg.names.Close(), sg.prefixes.Close(), sg.data.Close()}
+	if sg.spell != nil {
+		errs = append(errs, sg.spell.terms.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (sg *suggester) suggest(folded string, k int) ([]Suggestion, error) {
+	if utf8.RuneCountInString(folded) <= suggestPrefixRunes {
+		off, ok, err := sg.prefixes.Get([]byte(folded))
+		if err != nil {
+			return nil, fmt.Errorf("prefix fst get: %w", err)
+		}
+		if !ok {
+			return nil, nil
+		}
+		return sg.readList(int64(off), k)
+	}
+	return sg.scan(folded, k)
+}
+
+// readList decodes the top-K record stored at off in prefixes.dat.
+func (sg *suggester) readList(off int64, k int) ([]Suggestion, error) {
+	buf := make([]byte, 1024)
+	n, err := sg.data.ReadAt(buf, off)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read prefix data: %w", err)
+	}
+	buf = buf[:n]
+	recLen, hn := binary.Uvarint(buf)
+	if hn <= 0 {
+		return nil, fmt.Errorf("corrupt prefix record at %d", off)
+	}
+	if total := hn + int(recLen); total > len(buf) {
+		buf = make([]byte, total)
+		if _, err := sg.data.ReadAt(buf, off); err != nil {
+			return nil, fmt.Errorf("read prefix data: %w", err)
+		}
+	}
+
+	r := bytes.NewReader(buf[hn : hn+int(recLen)])
+	var out []Suggestion
+	for r.Len() > 0 && len(out) < k {
+		w, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("read weight: %w", err)
+		}
+		l, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("read text length: %w", err)
+		}
+		text := make([]byte, l)
+		if _, err := io.ReadFull(r, text); err != nil {
+			return nil, fmt.Errorf("read text: %w", err)
+		}
+		out = append(out, Suggestion{Text: string(text), Weight: w})
+	}
+	return out, nil
+}
+
+// scan walks the names FST range [folded, successor(folded)) and keeps the
+// k heaviest names, visiting at most suggestMaxScan entries.
+func (sg *suggester) scan(folded string, k int) ([]Suggestion, error) {
+	start := []byte(folded)
+	it, err := sg.names.Iterator(start, prefixSuccessor(start))
+	t := &topK{k: k}
+	for n := 0; err == nil && n < suggestMaxScan; n++ {
+		key, w := it.Current()
+		t.add(Suggestion{Text: string(key), Weight: w})
+		err = it.Next()
+	}
+	if err != nil && err != vellum.ErrIteratorDone {
+		return nil, fmt.Errorf("names fst scan: %w", err)
+	}
+	return t.items, nil
+}
+
+// prefixSuccessor returns the smallest key greater than every key with the
+// given prefix, or nil when no such key exists.
+func prefixSuccessor(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
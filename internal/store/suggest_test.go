@@ -0,0 +1,106 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	dir := t.TempDir()
+
+	b := NewSuggestBuilder()
+	for _, n := range []string{
+		"Coca-Cola", "Coca Cola", "coca cola", // weight 3 after folding
+		"Coca Cola Zero", "Coca Cola Zero", // weight 2
+		"Cocoa Powder",
+		"Chocolate Milk", "Chocolate Milk",
+		"Chocolate Chip Cookies",
+		"Crème Fraîche",
+		"",
+	} {
+		b.Add(n)
+	}
+	if b.Len() != 6 {
+		t.Fatalf("Len() = %d; want 6", b.Len())
+	}
+	if err := b.Write(dir); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	sg, err := openSuggester(dir)
+	if err != nil {
+		t.Fatalf("openSuggester: %v", err)
+	}
+	defer sg.Close()
+	s := &Store{suggest: sg}
+
+	tests := []struct {
+		q    string
+		k    int
+		want []string
+	}{
+		// Precomputed prefix path (≤ suggestPrefixRunes runes)
+		{"c", 3, []string{"coca cola", "chocolate milk", "coca cola zero"}},
+		{"Coc", 10, []string{"coca cola", "coca cola zero", "cocoa powder"}},
+		{"crè", 10, []string{"creme fraiche"}},
+		// Range-scan path (> suggestPrefixRunes runes)
+		{"chocolate", 10, []string{"chocolate milk", "chocolate chip cookies"}},
+		{"coca cola z", 10, []string{"coca cola zero"}},
+		// No match
+		{"xyz", 10, nil},
+		{"zzzzzzzz", 10, nil},
+	}
+	for _, tc := range tests {
+		got, err := s.Suggest(tc.q, tc.k)
+		if err != nil {
+			t.Fatalf("Suggest(%q): %v", tc.q, err)
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("Suggest(%q) = %v; want %v", tc.q, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i].Text != tc.want[i] {
+				t.Errorf("Suggest(%q)[%d] = %q; want %q", tc.q, i, got[i].Text, tc.want[i])
+			}
+		}
+	}
+
+	got, _ := s.Suggest("coca", 1)
+	if len(got) != 1 || got[0].Weight != 3 {
+		t.Errorf("Suggest(\"coca\", 1) = %v; want [{coca cola 3}]", got)
+	}
+}
+
+func TestSuggest_Unavailable(t *testing.T) {
+	sg, err := openSuggester(t.TempDir())
+	if err != nil || sg != nil {
+		t.Fatalf("openSuggester(empty dir) = %v, %v; want nil, nil", sg, err)
+	}
+	s := &Store{}
+	if _, err := s.Suggest("abc", 5); err != ErrSuggestUnavailable {
+		t.Errorf("Suggest err = %v; want ErrSuggestUnavailable", err)
+	}
+}
+
+func TestSuggestBuilder_Remove(t *testing.T) {
+	b := NewSuggestBuilder()
+	b.Add("Coca Cola")
+	b.Add("Coca-Cola")
+	b.Add("Cola Light")
+
+	b.Remove("coca cola")
+	if b.weights["coca cola"] != 1 || b.terms["cola"] != 2 {
+		t.Errorf("after one Remove: weights=%v terms=%v", b.weights, b.terms)
+	}
+	b.Remove("Coca Cola")
+	if _, ok := b.weights["coca cola"]; ok || b.Len() != 1 {
+		t.Errorf("after second Remove: weights=%v", b.weights)
+	}
+	if _, ok := b.terms["coca"]; ok || b.terms["cola"] != 1 {
+		t.Errorf("after second Remove: terms=%v", b.terms)
+	}
+	b.Remove("never added")
+	if b.Len() != 1 {
+		t.Errorf("Remove of unknown name changed Len to %d", b.Len())
+	}
+}
@@ -0,0 +1,254 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SynonymsFile is the default synonyms file name looked up in the data dir.
+const SynonymsFile = "synonyms.txt"
+
+// Synonyms is a reloadable query-time synonym dictionary.
+//
+// File format, one group per line:
+//
+//	# comment
+//	coke, coca cola              equivalent terms, any language
+//	en: ketchup, tomato ketchup  equivalent terms, English queries only
+//	de: nutella => nuss nougat creme   one-way: left side expands to right
+//
+// Terms may be multi-word and are folded with FoldName.
+type Synonyms struct {
+	path string
+
+	mu      sync.Mutex // serialises Reload
+	modTime time.Time
+	table   atomic.Pointer[synonymTable]
+	version atomic.Uint64 // bumped on every successful load
+}
+
+// synonymTable maps a folded phrase to its alternatives per language.
+// The "" language key holds entries that apply to every language.
+type synonymTable struct {
+	byLang    map[string]map[string][]string
+	maxTokens int
+}
+
+// LoadSynonyms parses the synonyms file at path.
+func LoadSynonyms(path string) (*Synonyms, error) {
+	sy := &Synonyms{path: path}
+	if _, err := sy.Reload(); err != nil {
+		return nil, err
+	}
+	return sy, nil
+}
+
+// Path returns the file the dictionary is loaded from.
+func (sy *Synonyms) Path() string {
+	return sy.path
+}
+
+// Reload re-reads the file if its modification time changed.
+// It reports whether a new table was installed. On error the previous table
+// stays active.
+func (sy *Synonyms) Reload() (bool, error) {
+	sy.mu.Lock()
+	defer sy.mu.Unlock()
+
+	fi, err := os.Stat(sy.path)
+	if err != nil {
+		return false, fmt.Errorf("stat synonyms: %w", err)
+	}
+	if sy.table.Load() != nil && fi.ModTime().Equal(sy.modTime) {
+		return false, nil
+	}
+
+	f, err := os.Open(sy.path)
+	if err != nil {
+		return false, fmt.Errorf("open synonyms: %w", err)
+	}
+	defer f.Close()
+
+	t, err := parseSynonyms(f)
+	if err != nil {
+		return false, fmt.Errorf("parse synonyms %s: %w", sy.path, err)
+	}
+	sy.table.Store(t)
+	sy.version.Add(1)
+	sy.modTime = fi.ModTime()
+	return true, nil
+}
+
+// Version counts successful loads so callers can tell when the table changed.
+// It is 0 for a nil dictionary.
+func (sy *Synonyms) Version() uint64 {
+	if sy == nil {
+		return 0
+	}
+	return sy.version.Load()
+}
+
+// Watch polls the file every interval and reloads it on change until ctx is
+// cancelled. Reload errors are logged and the previous table is kept.
+func (sy *Synonyms) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := sy.Reload()
+			if err != nil {
+				slog.Warn("synonyms reload failed", "path", sy.path, "error", err)
+			} else if changed {
+				slog.Info("synonyms reloaded", "path", sy.path)
+			}
+		}
+	}
+}
+
+func parseSynonyms(r io.Reader) (*synonymTable, error) {
+	t := &synonymTable{byLang: make(map[string]map[string][]string)}
+	add := func(lang, from, to string) {
+		m := t.byLang[lang]
+		if m == nil {
+			m = make(map[string][]string)
+			t.byLang[lang] = m
+		}
+		for _, existing := range m[from] {
+			if existing == to {
+				return
+			}
+		}
+		m[from] = append(m[from], to)
+		if n := len(strings.Fields(from)); n > t.maxTokens {
+			t.maxTokens = n
+		}
+	}
+
+	sc := bufio.NewScanner(r)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lang := ""
+		if i := strings.Index(line, ":"); i > 0 && !strings.ContainsAny(line[:i], " ,=") {
+			lang = strings.ToLower(line[:i])
+			line = line[i+1:]
+		}
+
+		if lhs, rhs, ok := strings.Cut(line, "=>"); ok {
+			from, to := foldList(lhs), foldList(rhs)
+			if len(from) == 0 || len(to) == 0 {
+				return nil, fmt.Errorf("line %d: empty side of '=>'", lineNo)
+			}
+			for _, a := range from {
+				for _, b := range to {
+					if a != b {
+						add(lang, a, b)
+					}
+				}
+			}
+			continue
+		}
+
+		terms := foldList(line)
+		if len(terms) < 2 {
+			return nil, fmt.Errorf("line %d: need at least two terms", lineNo)
+		}
+		for _, a := range terms {
+			for _, b := range terms {
+				if a != b {
+					add(lang, a, b)
+				}
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// foldList splits a comma-separated list and folds each entry.
+func foldList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if f := FoldName(part); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// synonymExpansion is one alternative spelling of the whole folded query.
+type synonymExpansion struct {
+	Phrase string // the alternative for the matched span alone
+	Query  string // the full query with the span replaced
+}
+
+// expand finds synonym phrases in the folded query (longest match first) and
+// returns one rewritten query per alternative. Entries scoped to lang apply
+// together with unscoped ones; an empty lang applies every entry.
+func (sy *Synonyms) expand(folded, lang string) []synonymExpansion {
+	if sy == nil {
+		return nil
+	}
+	t := sy.table.Load()
+	if t == nil || t.maxTokens == 0 {
+		return nil
+	}
+
+	lookup := func(phrase string) []string {
+		if lang != "" {
+			return append(append([]string(nil), t.byLang[""][phrase]...), t.byLang[lang][phrase]...)
+		}
+		var alts []string
+		for _, m := range t.byLang {
+			alts = append(alts, m[phrase]...)
+		}
+		return alts
+	}
+
+	tokens := strings.Fields(folded)
+	var out []synonymExpansion
+	seen := make(map[string]struct{})
+	for i := 0; i < len(tokens); {
+		matched := 0
+		for n := min(t.maxTokens, len(tokens)-i); n > 0; n-- {
+			phrase := strings.Join(tokens[i:i+n], " ")
+			alts := lookup(phrase)
+			if len(alts) == 0 {
+				continue
+			}
+			for _, alt := range alts {
+				q := strings.Join(append(append(append([]string(nil), tokens[:i]...), alt), tokens[i+n:]...), " ")
+				if _, dup := seen[q]; dup || q == folded {
+					continue
+				}
+				seen[q] = struct{}{}
+				out = append(out, synonymExpansion{Phrase: alt, Query: q})
+			}
+			matched = n
+			break
+		}
+		if matched == 0 {
+			matched = 1
+		}
+		i += matched
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSynonyms(t *testing.T) {
+	src := `
+# comment
+coke, Coca-Cola
+en: ketchup, tomato ketchup
+hazelnut spread => nutella
+`
+	tbl, err := parseSynonyms(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("parseSynonyms: %v", err)
+	}
+	if got := tbl.byLang[""]["coke"]; len(got) != 1 || got[0] != "coca cola" {
+		t.Errorf("coke → %v; want [coca cola]", got)
+	}
+	if got := tbl.byLang[""]["coca cola"]; len(got) != 1 || got[0] != "coke" {
+		t.Errorf("coca cola → %v; want [coke]", got)
+	}
+	if got := tbl.byLang["en"]["ketchup"]; len(got) != 1 || got[0] != "tomato ketchup" {
+		t.Errorf("en ketchup → %v; want [tomato ketchup]", got)
+	}
+	if got := tbl.byLang[""]["nutella"]; len(got) != 0 {
+		t.Errorf("one-way rule expanded backwards: nutella → %v", got)
+	}
+	if tbl.maxTokens != 2 {
+		t.Errorf("maxTokens = %d; want 2", tbl.maxTokens)
+	}
+
+	if _, err := parseSynonyms(strings.NewReader("lonely\n")); err == nil {
+		t.Error("expected error for single-term line")
+	}
+}
+
+func TestSynonymsExpand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SynonymsFile)
+	if err := os.WriteFile(path, []byte("coke, coca cola\nde: joghurt, yoghurt\nhazelnut spread => nutella\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sy, err := LoadSynonyms(path)
+	if err != nil {
+		t.Fatalf("LoadSynonyms: %v", err)
+	}
+
+	tests := []struct {
+		q, lang string
+		want    []string
+	}{
+		{"coke zero", "en", []string{"coca cola zero"}},
+		{"hazelnut spread 400g", "", []string{"nutella 400g"}},
+		{"joghurt", "de", []string{"yoghurt"}},
+		{"joghurt", "fr", nil},
+		{"joghurt", "", []string{"yoghurt"}},
+		{"milk", "", nil},
+	}
+	for _, tc := range tests {
+		var got []string
+		for _, e := range sy.expand(tc.q, tc.lang) {
+			got = append(got, e.Query)
+		}
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+			t.Errorf("expand(%q, %q) = %v; want %v", tc.q, tc.lang, got, tc.want)
+		}
+	}
+
+	// Reload picks up edits without a restart.
+	if err := os.WriteFile(path, []byte("coke, cola\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := sy.Reload()
+	if err != nil || !changed {
+		t.Fatalf("Reload() = %v, %v; want true, nil", changed, err)
+	}
+	if got := sy.expand("coke", ""); len(got) != 1 || got[0].Query != "cola" {
+		t.Errorf("after reload expand(coke) = %v; want [cola]", got)
+	}
+}
+
+func TestSearch_Synonyms(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Create(dir)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer s.Close()
+
+	batch := s.NewWriteBatch()
+	batch.Put(Product{Barcode: "1", Name: "Coca-Cola Classic"})
+	batch.Put(Product{Barcode: "2", Name: "Orange Juice"})
+	if err := batch.Close(); err != nil {
+		t.Fatalf("batch close: %v", err)
+	}
+
+	path := filepath.Join(dir, SynonymsFile)
+	if err := os.WriteFile(path, []byte("coke, coca cola\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sy, err := LoadSynonyms(path)
+	if err != nil {
+		t.Fatalf("LoadSynonyms: %v", err)
+	}
+
+	if res, _ := s.Search("coke", 10); len(res) != 0 {
+		t.Fatalf("without synonyms got %d results; want 0", len(res))
+	}
+	s.SetSynonyms(sy)
+	res, err := s.Search("coke", 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(res) == 0 || res[0].Barcode != "1" {
+		t.Errorf("Search(coke) = %v; want barcode 1 first", res)
+	}
+}
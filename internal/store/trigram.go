@@ -0,0 +1,260 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/korjavin/fastfooddb/internal/barcode"
+)
+
+const (
+	// trigramMinShare is the share of the query's trigrams a name must
+	// contain to be a candidate. Half of them still admits one or two typos
+	// in a word.
+	trigramMinShare = 0.5
+
+	// trigramPrefixBonus and trigramWordBonus are added to the similarity
+	// when the name starts with the whole query, or contains it starting at a
+	// word boundary.
+	trigramPrefixBonus = 0.5
+	trigramWordBonus   = 0.25
+)
+
+// TrigramSearcher is an in-memory ProductSearcher over folded product names.
+// Each word is padded with spaces and split into rune trigrams; a name is a
+// candidate when it shares at least trigramMinShare of the query's trigrams.
+// Candidates are ranked by the Dice coefficient of the two trigram sets, plus
+// a bonus for prefix and whole-word matches, scaled by popularity like the
+// Bleve rerank. Names, barcodes and postings are kept in flat, delta-encoded
+// arrays so the index stays a small multiple of the names' size.
+//
+// SearchOptions.Lang is ignored: there is no stemming. Products are read
+// through the ProductLookup it was built from.
+type TrigramSearcher struct {
+	lookup   ProductLookup
+	barcodes []byte // concatenated barcodes, ends in docs
+	names    []byte // concatenated folded names, ends in docs
+	docs     []trigramDoc
+	postings map[uint64][]byte // trigram → uvarint deltas of ascending doc ids
+	counters sync.Pool         // *trigramCounter sized to docs
+}
+
+type trigramDoc struct {
+	barcodeEnd uint32
+	nameEnd    uint32
+	grams      uint16 // distinct trigrams in the name
+	quality    QualityFlags
+	popularity float32
+}
+
+// trigramCounter accumulates shared trigrams per document for one query.
+type trigramCounter struct {
+	counts  []uint16
+	touched []uint32
+}
+
+type trigramHit struct {
+	doc   uint32
+	score float64
+}
+
+// NewTrigramSearcher indexes the name of every product in s. Products
+// without a name are not searchable, as with Bleve.
+func NewTrigramSearcher(s *Store) (*TrigramSearcher, error) {
+	t := &TrigramSearcher{lookup: s}
+	lists := make(map[uint64][]uint32)
+
+	it, err := s.db.NewIter(nil)
+	if err != nil {
+		return nil, fmt.Errorf("pebble iter: %w", err)
+	}
+	for valid := it.First(); valid; valid = it.Next() {
+		var p Product
+		if err := p.Decode(it.Value()); err != nil {
+			_ = it.Close()
+			return nil, fmt.Errorf("decode product %q: %w", it.Key(), err)
+		}
+		folded := FoldName(p.Name)
+		if folded == "" {
+			continue
+		}
+		id := uint32(len(t.docs))
+		grams := trigrams(folded)
+		for _, g := range grams {
+			lists[g] = append(lists[g], id)
+		}
+		t.barcodes = append(t.barcodes, it.Key()...)
+		t.names = append(t.names, folded...)
+		t.docs = append(t.docs, trigramDoc{
+			barcodeEnd: uint32(len(t.barcodes)),
+			nameEnd:    uint32(len(t.names)),
+			grams:      uint16(min(len(grams), math.MaxUint16)),
+			quality:    p.Quality,
+			popularity: p.Popularity,
+		})
+	}
+	if err := errors.Join(it.Error(), it.Close()); err != nil {
+		return nil, fmt.Errorf("pebble iter: %w", err)
+	}
+
+	t.postings = make(map[uint64][]byte, len(lists))
+	for g, ids := range lists {
+		t.postings[g] = encodePostings(ids)
+	}
+	n := len(t.docs)
+	t.counters.New = func() any { return &trigramCounter{counts: make([]uint16, n)} }
+	return t, nil
+}
+
+// Len returns the number of indexed names.
+func (t *TrigramSearcher) Len() int {
+	return len(t.docs)
+}
+
+// trigrams returns the distinct trigrams of the space-padded words of a
+// folded string, each packed as three 21-bit runes.
+func trigrams(folded string) []uint64 {
+	var out []uint64
+	for _, word := range strings.Fields(folded) {
+		r := append(append([]rune{' '}, []rune(word)...), ' ')
+		for i := 0; i+3 <= len(r); i++ {
+			g := uint64(r[i])<<42 | uint64(r[i+1])<<21 | uint64(r[i+2])
+			if !slices.Contains(out, g) {
+				out = append(out, g)
+			}
+		}
+	}
+	return out
+}
+
+func encodePostings(ids []uint32) []byte {
+	buf := make([]byte, 0, len(ids))
+	prev := uint32(0)
+	for _, id := range ids {
+		buf = binary.AppendUvarint(buf, uint64(id-prev))
+		prev = id
+	}
+	return buf
+}
+
+func (t *TrigramSearcher) barcode(id uint32) string {
+	start := uint32(0)
+	if id > 0 {
+		start = t.docs[id-1].barcodeEnd
+	}
+	return string(t.barcodes[start:t.docs[id].barcodeEnd])
+}
+
+func (t *TrigramSearcher) name(id uint32) []byte {
+	start := uint32(0)
+	if id > 0 {
+		start = t.docs[id-1].nameEnd
+	}
+	return t.names[start:t.docs[id].nameEnd]
+}
+
+// SearchWith ranks indexed names against q and loads the top products.
+// Collapsing widens the window like the Bleve backend until it fills a page.
+func (t *TrigramSearcher) SearchWith(q string, opts SearchOptions) ([]Product, error) {
+	limit := searchLimit(opts.Limit)
+	folded := FoldName(q)
+	grams := trigrams(folded)
+	if len(grams) == 0 {
+		return nil, nil
+	}
+	hits := t.rank(folded, grams, opts)
+
+	size := limit
+	if opts.Collapse {
+		size = limit * rerankWindow
+	}
+	var products []Product
+	for fetched := 0; ; {
+		for _, h := range hits[fetched:min(size, len(hits))] {
+			p, found, err := t.lookup.Get(t.barcode(h.doc))
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				products = append(products, p)
+			}
+		}
+		fetched = min(size, len(hits))
+		if !opts.Collapse {
+			return head(products, limit), nil
+		}
+		groups := collapseProducts(products)
+		if len(groups) >= limit || fetched == len(hits) || size >= maxCollapseWindow {
+			return head(groups, limit), nil
+		}
+		size = min(size*2, maxCollapseWindow)
+	}
+}
+
+// rank returns the candidates passing opts' filters, best first; ties go to
+// the lower barcode.
+func (t *TrigramSearcher) rank(folded string, grams []uint64, opts SearchOptions) []trigramHit {
+	c := t.counters.Get().(*trigramCounter)
+	defer t.counters.Put(c)
+
+	for _, g := range grams {
+		p := t.postings[g]
+		id := uint32(0)
+		for len(p) > 0 {
+			delta, n := binary.Uvarint(p)
+			p = p[n:]
+			id += uint32(delta)
+			if c.counts[id] == 0 {
+				c.touched = append(c.touched, id)
+			}
+			c.counts[id]++
+		}
+	}
+
+	minShared := uint16(math.Ceil(float64(len(grams)) * trigramMinShare))
+	country := strings.ToUpper(opts.GS1Country)
+	prefix, word := []byte(folded), []byte(" "+folded)
+	var hits []trigramHit
+	for _, id := range c.touched {
+		shared := c.counts[id]
+		c.counts[id] = 0
+		if shared < minShared {
+			continue
+		}
+		d := t.docs[id]
+		if opts.ExcludeLowQuality && d.quality&LowQuality != 0 {
+			continue
+		}
+		if country != "" {
+			if gs1, ok := barcode.GS1Country(t.barcode(id)); !ok || !slices.Contains(gs1.Codes(), country) {
+				continue
+			}
+		}
+		name := t.name(id)
+		score := 2 * float64(shared) / float64(len(grams)+int(d.grams))
+		switch {
+		case bytes.HasPrefix(name, prefix):
+			score += trigramPrefixBonus
+		case bytes.Contains(name, word):
+			score += trigramWordBonus
+		}
+		score *= popularityBoost(d.popularity)
+		hits = append(hits, trigramHit{doc: id, score: score})
+	}
+	c.touched = c.touched[:0]
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].doc < hits[j].doc
+	})
+	return hits
+}
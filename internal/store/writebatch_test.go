@@ -1,6 +1,7 @@
 package store
 
 import (
+	"fmt"
 	"math"
 	"os"
 	"testing"
@@ -116,3 +117,229 @@ func TestWriteBatch_SearchAfterFlush(t *testing.T) {
 		t.Errorf("top result barcode = %q; want %q", results[0].Barcode, "111")
 	}
 }
+
+func TestSearch_StemmedLanguageFields(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Create(dir)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer s.Close()
+
+	batch := s.NewWriteBatch()
+	batch.Put(Product{Barcode: "111", Name: "Mixed Berry Jam", Lang: "en"})
+	batch.Put(Product{Barcode: "222", Name: "Haus Brot", Lang: "de"})
+	batch.Put(Product{Barcode: "333", Name: "Berry Tea", Lang: "xx"}) // no stemmed field
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	tests := []struct {
+		q, lang string
+		want    string
+	}{
+		// Too far apart for the fuzzy clauses; only stemming can match.
+		{"berries", "en", "111"},
+		{"berries", "", "111"},
+		{"Häuser", "de", "222"},
+		{"Häuser", "", "222"},
+	}
+	for _, tc := range tests {
+		results, err := s.SearchWith(tc.q, SearchOptions{Limit: 10, Lang: tc.lang})
+		if err != nil {
+			t.Fatalf("SearchWith(%q, %q): %v", tc.q, tc.lang, err)
+		}
+		if len(results) != 1 || results[0].Barcode != tc.want {
+			t.Errorf("SearchWith(%q, %q) = %v; want only %s", tc.q, tc.lang, results, tc.want)
+		}
+	}
+}
+
+func TestSearch_PopularityRerank(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Create(dir)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer s.Close()
+
+	batch := s.NewWriteBatch()
+	batch.Put(Product{Barcode: "001", Name: "Coca-Cola"})
+	batch.Put(Product{Barcode: "002", Name: "Coca-Cola", Popularity: 8}) // ~3000 scans
+	batch.Put(Product{Barcode: "003", Name: "Coca-Cola"})
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	results, err := s.Search("coca cola", 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results; want 3", len(results))
+	}
+	if results[0].Barcode != "002" {
+		t.Errorf("top result = %q; want popular product 002", results[0].Barcode)
+	}
+
+	// Popularity never pushes results past the requested limit.
+	results, err = s.Search("coca cola", 1)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Barcode != "002" {
+		t.Errorf("Search limit 1 = %v; want [002]", results)
+	}
+}
+
+func TestSearch_GS1CountryFilter(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Create(dir)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer s.Close()
+
+	batch := s.NewWriteBatch()
+	batch.Put(Product{Barcode: "4000417025005", Name: "Apfelsaft"}) // DE
+	batch.Put(Product{Barcode: "3017620422003", Name: "Apfelsaft"}) // FR
+	batch.Put(Product{Barcode: "2000000000001", Name: "Apfelsaft"}) // in-store, no country
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for _, tc := range []struct {
+		country string
+		want    int
+	}{{"", 3}, {"de", 1}, {"FR", 1}, {"IT", 0}} {
+		results, err := s.SearchWith("apfelsaft", SearchOptions{Limit: 10, GS1Country: tc.country})
+		if err != nil {
+			t.Fatalf("SearchWith(%q): %v", tc.country, err)
+		}
+		if len(results) != tc.want {
+			t.Errorf("gs1_country=%q: got %d results; want %d", tc.country, len(results), tc.want)
+		}
+	}
+}
+
+func TestSearch_ExcludeLowQuality(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Create(dir)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer s.Close()
+
+	batch := s.NewWriteBatch()
+	batch.Put(Product{Barcode: "001", Name: "Haferflocken"})
+	batch.Put(Product{Barcode: "002", Name: "Haferflocken", Quality: QualityKcalInferred})
+	batch.Put(Product{Barcode: "003", Name: "Haferflocken", Quality: QualityEnergyMismatch})
+	batch.Put(Product{Barcode: "004", Name: "Haferflocken", Quality: QualityMacrosExceed100g | QualityKcalInferred})
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for _, tc := range []struct {
+		exclude bool
+		want    int
+	}{{false, 4}, {true, 2}} {
+		results, err := s.SearchWith("haferflocken", SearchOptions{Limit: 10, ExcludeLowQuality: tc.exclude})
+		if err != nil {
+			t.Fatalf("SearchWith: %v", err)
+		}
+		if len(results) != tc.want {
+			t.Errorf("exclude_low_quality=%v: got %d results; want %d", tc.exclude, len(results), tc.want)
+		}
+	}
+}
+
+func TestSearch_Sharded(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := CreateSharded(dir, 4)
+	if err != nil {
+		t.Fatalf("CreateSharded: %v", err)
+	}
+	batch := s.NewWriteBatch()
+	for i := range 40 {
+		batch.Put(Product{Barcode: fmt.Sprintf("%013d", i), Name: fmt.Sprintf("Tomato Soup %d", i)})
+	}
+	batch.Put(Product{Barcode: "9999999999999", Name: "Cheddar"})
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close batch: %v", err)
+	}
+	batch = s.NewWriteBatch()
+	batch.Put(Product{Barcode: "9999999999999"})
+	batch.Unindex("9999999999999")
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close batch: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rs, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer rs.Close()
+
+	if rs.Shards() != 4 {
+		t.Errorf("Shards() = %d; want 4", rs.Shards())
+	}
+	results, err := rs.Search("tomato soup", 100)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 40 {
+		t.Errorf("Search across shards returned %d results; want 40", len(results))
+	}
+	if results, _ := rs.Search("cheddar", 10); len(results) != 0 {
+		t.Errorf("unindexed product still found: %v", results)
+	}
+	if _, ok, _ := rs.Get("9999999999999"); !ok {
+		t.Error("Get after Unindex: product missing from pebble")
+	}
+}
+
+func TestGet_MissUsesBloomFilter(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Create(dir)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	batch := s.NewWriteBatch()
+	for i := range 1000 {
+		batch.Put(Product{Barcode: fmt.Sprintf("%013d", 2*i), Name: "Milk"})
+	}
+	if err := batch.Close(); err != nil {
+		t.Fatalf("Close batch: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rs, err := OpenReadOnlyWith(dir, PebbleOptions{BlockCacheSize: 1 << 20, MaxOpenFiles: 16})
+	if err != nil {
+		t.Fatalf("OpenReadOnlyWith: %v", err)
+	}
+	defer rs.Close()
+
+	// Odd barcodes fall inside the table's key range but are absent.
+	for i := range 100 {
+		if _, ok, err := rs.Get(fmt.Sprintf("%013d", 2*i+1)); err != nil || ok {
+			t.Fatalf("Get miss = %v, %v", ok, err)
+		}
+	}
+	if _, ok, _ := rs.Get("0000000000500"); !ok {
+		t.Fatal("Get hit: product missing")
+	}
+	// Misses are rejected by the table filter rather than read from blocks.
+	if hits := rs.db.Metrics().Filter.Hits; hits < 90 {
+		t.Errorf("filter hits = %d; want most of the 100 misses", hits)
+	}
+}